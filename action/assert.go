@@ -2,6 +2,7 @@ package action
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,13 @@ const (
 	NoFailure = "nofailure"
 	// SLOs states that all app versions participating in the experiment satisfy SLOs
 	SLOs = "slos"
+	// Decided states that sequential testing has settled on promoting or rolling back a version
+	Decided = "decided"
+	// NoRegression states that no metric of any app version regressed compared with earlier runs of the experiment
+	NoRegression = "noregression"
+	// Score states that the canary scores of all candidate versions are at least the given value
+	// Example: score>=80
+	Score = "score>="
 )
 
 // AssertOpts are the options used for asserting experiment results
@@ -100,6 +108,42 @@ func (aOpts *AssertOpts) verify(eio base.Driver) (bool, error) {
 				} else {
 					log.Logger.Info("SLOs are not satisfied")
 				}
+			} else if strings.ToLower(cond) == Decided {
+				d := exp.Decision()
+				decided := d == string(base.PromoteDecision) || d == string(base.RollbackDecision)
+				allGood = allGood && decided
+				if decided {
+					log.Logger.Info("sequential tests decided to ", d)
+				} else {
+					log.Logger.Info("sequential tests have not decided yet")
+				}
+			} else if strings.ToLower(cond) == NoRegression {
+				nr := exp.NoRegression()
+				allGood = allGood && nr
+				if nr {
+					log.Logger.Info("no regressions compared with earlier runs")
+				} else {
+					log.Logger.Info("metrics regressed compared with earlier runs")
+				}
+			} else if strings.HasPrefix(strings.ToLower(cond), Score) {
+				minScore, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(strings.ToLower(cond), Score)), 64)
+				if err != nil {
+					log.Logger.Error("invalid score in assert condition; ", cond)
+					return false, fmt.Errorf("invalid score in assert condition; %v", cond)
+				}
+				var lowest *float64
+				if exp.Result.Insights != nil && exp.Result.Insights.CanaryScores != nil {
+					lowest = exp.Result.Insights.CanaryScores.Lowest()
+				}
+				ok := lowest != nil && *lowest >= minScore
+				allGood = allGood && ok
+				if lowest == nil {
+					log.Logger.Info("canary scores are not available")
+				} else if ok {
+					log.Logger.Infof("canary score %v is at least %v", *lowest, minScore)
+				} else {
+					log.Logger.Infof("canary score %v is less than %v", *lowest, minScore)
+				}
 			} else {
 				log.Logger.Error("unsupported assert condition detected; ", cond)
 				return false, fmt.Errorf("unsupported assert condition detected; %v", cond)
@@ -2,6 +2,7 @@ package action
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"testing"
 
@@ -32,3 +33,143 @@ func TestKubeAssert(t *testing.T) {
 	assert.True(t, ok)
 	assert.NoError(t, err)
 }
+
+func TestKubeAssertDecided(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	aOpts.Conditions = []string{Decided}
+
+	exp := `
+spec:
+- task: assess
+  with:
+    rewards:
+      max: ["http/latency-mean"]
+    sequential: {}
+result:
+  numLoops: 2
+  numCompletedTasks: 1
+  decision: %v
+  iter8Version: v0.13
+`
+	_, _ = aOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: fmt.Sprintf(exp, base.PromoteDecision)},
+	}, metav1.CreateOptions{})
+
+	ok, err := aOpts.KubeRun()
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	// experiment has not decided yet
+	_, _ = aOpts.Clientset.CoreV1().Secrets("default").Update(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: fmt.Sprintf(exp, base.ContinueDecision)},
+	}, metav1.UpdateOptions{})
+
+	ok, err = aOpts.KubeRun()
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestKubeAssertNoRegression(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+	aOpts.Conditions = []string{NoRegression}
+
+	exp := `
+spec:
+- task: assess
+  with:
+    regression:
+      min: ["http/latency-mean"]
+result:
+  numLoops: 1
+  numCompletedTasks: 1
+  iter8Version: v0.13
+  insights:
+    numVersions: 1
+    regressions:
+      runs: 3
+      metrics: ["http/latency-mean"]
+      history: [[100]]
+      pValues: [[0.01]]
+      regressed: [[%v]]
+`
+	_, _ = aOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: fmt.Sprintf(exp, false)},
+	}, metav1.CreateOptions{})
+
+	ok, err := aOpts.KubeRun()
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	// latency regressed
+	_, _ = aOpts.Clientset.CoreV1().Secrets("default").Update(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: fmt.Sprintf(exp, true)},
+	}, metav1.UpdateOptions{})
+
+	ok, err = aOpts.KubeRun()
+	assert.False(t, ok)
+	assert.NoError(t, err)
+}
+
+func TestKubeAssertScore(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	aOpts := NewAssertOpts(driver.NewFakeKubeDriver(cli.New()))
+
+	_, _ = aOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: `
+spec:
+- task: assess
+  with:
+    score:
+      metrics:
+      - metric: http/latency-mean
+result:
+  numLoops: 1
+  numCompletedTasks: 1
+  insights:
+    numVersions: 3
+    canaryScores:
+      baseline: 0
+      metrics: ["http/latency-mean"]
+      classifications: [["", "pass", "high"]]
+      scores: [null, 100, 80]
+  iter8Version: v0.13
+`},
+	}, metav1.CreateOptions{})
+
+	aOpts.Conditions = []string{"score>=80"}
+	ok, err := aOpts.KubeRun()
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	// the lowest score of a candidate is used
+	aOpts.Conditions = []string{"score>=90"}
+	ok, err = aOpts.KubeRun()
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	aOpts.Conditions = []string{"score>=high"}
+	_, err = aOpts.KubeRun()
+	assert.Error(t, err)
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/iter8-tools/iter8/base/log"
 )
 
+// insufficientDataStr is reported when there is not enough data to draw a conclusion
+const insufficientDataStr = "insufficient data"
+
 // Reporter implements methods that are common to text and HTML reporting.
 type Reporter struct {
 	// Experiment enables access to all base.Experiment data and methods
@@ -72,6 +75,36 @@ func (r *Reporter) ScalarMetricValueStr(j int, mn string) string {
 	return "unavailable"
 }
 
+// SLOSatisfiedStr returns whether the upper or lower SLO (i) is satisfied by the given app version (j) as a string
+// Versions with insufficient data to evaluate the SLO are reported as such
+// The limit is included for versions whose limit is overridden by the SLO
+func (r *Reporter) SLOSatisfiedStr(upper bool, i int, j int) string {
+	sr := r.Result.Insights.SLOsSatisfied
+	if sr.InsufficientData(upper, i, j) {
+		return insufficientDataStr
+	}
+	var str string
+	if upper {
+		str = fmt.Sprint(sr.Upper[i][j])
+	} else {
+		str = fmt.Sprint(sr.Lower[i][j])
+	}
+	if limit := r.Result.Insights.SLOVersionLimitStr(i, j, upper); len(limit) > 0 {
+		str = fmt.Sprintf("%v (limit %v)", str, limit)
+	}
+	return str
+}
+
+// ScoreStr returns the canary score of the given app version (j) as a string
+// Versions without a score, for example versions detected after the scores were computed, are reported as unavailable
+func (r *Reporter) ScoreStr(j int) string {
+	cs := r.Result.Insights.CanaryScores
+	if cs == nil || j >= len(cs.Scores) || cs.Scores[j] == nil {
+		return "unavailable"
+	}
+	return fmt.Sprintf("%0.2f", *cs.Scores[j])
+}
+
 // MetricWithUnits provides the string representation of a metric name with units
 func (r *Reporter) MetricWithUnits(metricName string) (string, error) {
 	in := r.Result.Insights
@@ -106,19 +139,11 @@ func (r *Reporter) GetBestVersions(metrics []string, in *base.Insights) []string
 	for i, mn := range metrics {
 		j := indexString(rewards.Max, mn)
 		if j >= 0 {
-			if winners.Max[j] == -1 {
-				results[i] = "insufficient data"
-			} else {
-				results[i] = in.TrackVersionStr(winners.Max[j])
-			}
+			results[i] = bestVersionStr(in, winners.Max[j], rewardStatsAt(winners.MaxStats, j))
 		} else {
 			j = indexString(rewards.Min, mn)
 			if j >= 0 {
-				if winners.Min[j] == -1 {
-					results[i] = "insufficient data"
-				} else {
-					results[i] = in.TrackVersionStr(winners.Min[j])
-				}
+				results[i] = bestVersionStr(in, winners.Min[j], rewardStatsAt(winners.MinStats, j))
 			} else {
 				results[i] = "n/a"
 			}
@@ -127,6 +152,38 @@ func (r *Reporter) GetBestVersions(metrics []string, in *base.Insights) []string
 	return results
 }
 
+// rewardStatsAt returns the statistics of the reward metric at index j, if any
+func rewardStatsAt(rewardStats []*base.RewardStats, j int) *base.RewardStats {
+	if j < len(rewardStats) {
+		return rewardStats[j]
+	}
+	return nil
+}
+
+// bestVersionStr describes the winner of a reward metric
+// if significance was tested, the largest p-value among the comparisons with the winner is included
+func bestVersionStr(in *base.Insights, winner int, rs *base.RewardStats) string {
+	// largest p-value among comparisons
+	var p *float64
+	if rs != nil {
+		for _, pv := range rs.PValues {
+			if pv != nil && (p == nil || *pv > *p) {
+				p = pv
+			}
+		}
+	}
+	if winner == -1 {
+		if p != nil {
+			return "not significant"
+		}
+		return insufficientDataStr
+	}
+	if p != nil {
+		return fmt.Sprintf("%v (p=%0.3f)", in.TrackVersionStr(winner), *p)
+	}
+	return in.TrackVersionStr(winner)
+}
+
 func indexString(keys []string, item string) int {
 	for i, key := range keys {
 		if key == item {
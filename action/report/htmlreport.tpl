@@ -58,6 +58,35 @@
       });	
       </script>
 
+      {{- if .Result.TaskResults }}
+      <section class="mt-5">
+        <h3 class="display-6">Task execution</h3>
+        <hr>
+        <table class="table">
+          <thead class="thead-light">
+            <tr>
+              <th scope="col">Task</th>
+              <th scope="col" class="text-center">Status</th>
+              <th scope="col" class="text-center">Attempts</th>
+              <th scope="col" class="text-center">Duration</th>
+              <th scope="col">Error</th>
+            </tr>
+          </thead>
+          <tbody>
+              {{- range .Result.TaskResults }}
+              <tr scope="row">
+                <td>{{ .Name }}</td>
+                <td class="{{ if eq (toString .Status) "failed" }}table-danger{{ else if eq (toString .Status) "succeeded" }}table-success{{ end }} text-center">{{ .Status }}</td>
+                <td class="text-center">{{ .Attempts }}</td>
+                <td class="text-center">{{ .Duration }}</td>
+                <td>{{ .Error }}</td>
+              </tr>
+              {{- end }}
+          </tbody>
+        </table>
+      </section>
+      {{- end }}
+
       {{- if .Result.Insights }}
         {{- if not (empty .Result.Insights.SLOs) }}  
         <section class="mt-5">
@@ -84,28 +113,42 @@
                     <a href="javascript:void(0)" data-toggle="tooltip" data-placement="top" title="{{ $.MetricDescriptionHTML $slo.Metric }}">
                       {{ $.MetricWithUnits $slo.Metric }}
                     </a>
-                    &leq; {{ $slo.Limit -}}
+                    &leq; {{ $.Result.Insights.SLOLimitStr $ind true -}}
                   </td>
-                  {{- range (index $.Result.Insights.SLOsSatisfied.Upper $ind) }}
-                  <td class="{{ renderSLOSatisfiedCellClass .  }} text-center">
-                    <i class="far {{ renderSLOSatisfiedHTML . }}"></i>                
+                  {{- range $j, $sat := (index $.Result.Insights.SLOsSatisfied.Upper $ind) }}
+                  {{- if $.Result.Insights.SLOsSatisfied.InsufficientData true $ind $j }}
+                  <td class="text-warning text-center">insufficient data</td>
+                  {{- else }}
+                  <td class="{{ renderSLOSatisfiedCellClass $sat }} text-center">
+                    <i class="far {{ renderSLOSatisfiedHTML $sat }}"></i>
+                    {{- with $.Result.Insights.SLOVersionLimitStr $ind $j true }}
+                    <small class="text-muted">(limit {{ . }})</small>
+                    {{- end }}
                   </td>
                   {{- end }}
+                  {{- end }}
                 </tr>
                 {{- end}}
                 {{- range $ind, $slo := .Result.Insights.SLOs.Lower }}
                 <tr scope="row">
                   <td>
-                    {{- $slo.Limit }} &leq;
+                    {{- $.Result.Insights.SLOLimitStr $ind false }} &leq;
                     <a href="javascript:void(0)" data-toggle="tooltip" data-placement="top" title="{{ $.MetricDescriptionHTML $slo.Metric }}">
                       {{ $.MetricWithUnits $slo.Metric }}
                     </a>
                   </td>
-                  {{- range (index $.Result.Insights.SLOsSatisfied.Lower $ind) }}
-                  <td class="{{ renderSLOSatisfiedCellClass .  }} text-center">
-                    <i class="far {{ renderSLOSatisfiedHTML . }}"></i>                
+                  {{- range $j, $sat := (index $.Result.Insights.SLOsSatisfied.Lower $ind) }}
+                  {{- if $.Result.Insights.SLOsSatisfied.InsufficientData false $ind $j }}
+                  <td class="text-warning text-center">insufficient data</td>
+                  {{- else }}
+                  <td class="{{ renderSLOSatisfiedCellClass $sat }} text-center">
+                    <i class="far {{ renderSLOSatisfiedHTML $sat }}"></i>
+                    {{- with $.Result.Insights.SLOVersionLimitStr $ind $j false }}
+                    <small class="text-muted">(limit {{ . }})</small>
+                    {{- end }}
                   </td>
                   {{- end }}
+                  {{- end }}
                 </tr>
                 {{- end}}                 
             </tbody>
@@ -113,6 +156,47 @@
         </section>
         {{- end }}
 
+        {{- if .Result.Insights.CanaryScores }}
+        {{- $cs := .Result.Insights.CanaryScores }}
+        <section class="mt-5">
+          <h3 class="display-6">Canary scores</h3>
+          <h4 class="display-7 text-muted">Comparison of metrics with {{ .Result.Insights.TrackVersionStr $cs.Baseline }}</h4>
+          <hr>
+          <table class="table">
+            <thead class="thead-light">
+              <tr>
+                <th scope="col">Metric</th>
+                {{- range until .Result.Insights.NumVersions }}
+                {{- if ne . $cs.Baseline }}
+                <th scope="col" class="text-center">{{ $.Result.Insights.TrackVersionStr . }}</th>
+                {{- end }}
+                {{- end }}
+              </tr>
+            </thead>
+            <tbody>
+              {{- range $ind, $mn := $cs.Metrics }}
+              <tr scope="row">
+                <td>{{ $mn }}</td>
+                {{- range $j, $c := index $cs.Classifications $ind }}
+                {{- if ne $j $cs.Baseline }}
+                <td class="text-center">{{ $c }}</td>
+                {{- end }}
+                {{- end }}
+              </tr>
+              {{- end }}
+              <tr scope="row">
+                <th scope="row">Score</th>
+                {{- range until .Result.Insights.NumVersions }}
+                {{- if ne . $cs.Baseline }}
+                <th scope="row" class="text-center">{{ $.ScoreStr . }}</th>
+                {{- end }}
+                {{- end }}
+              </tr>
+            </tbody>
+          </table>
+        </section>
+        {{- end }}
+
         {{ if (.SortedVectorMetrics) }}
         <section class="mt-5">
           <h3 class="display-6">Metric Histograms</h3>
@@ -1,10 +1,12 @@
 package report
 
 import (
+	"bytes"
 	"os"
 	"testing"
 
 	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/summarymetrics"
 	"github.com/iter8-tools/iter8/driver"
 	"github.com/stretchr/testify/assert"
 )
@@ -63,3 +65,189 @@ func TestReportHTMLWithLowerSLOs(t *testing.T) {
 	err = reporter.Gen(os.Stdout)
 	assert.NoError(t, err)
 }
+
+func TestReportWithTaskResults(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	_ = copyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	exp.Result.TaskResults = []base.TaskResult{
+		{Name: "http", Status: base.TaskSucceeded, Attempts: 1, Duration: "1.5s"},
+		{Name: "assess", Status: base.TaskFailed, Attempts: 2, Duration: "10ms", Error: "boom"},
+	}
+
+	tr := TextReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	txt := tr.PrintTaskResultsText()
+	assert.Contains(t, txt, "assess")
+	assert.Contains(t, txt, "failed")
+	assert.Contains(t, txt, "boom")
+	err = tr.Gen(os.Stdout)
+	assert.NoError(t, err)
+
+	hr := HTMLReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	err = hr.Gen(os.Stdout)
+	assert.NoError(t, err)
+}
+
+func TestReportInsufficientData(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	_ = copyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	sr := exp.Result.Insights.SLOsSatisfied
+	sr.InsufficientUpper = make([][]bool, len(sr.Upper))
+	for i := range sr.Upper {
+		sr.InsufficientUpper[i] = []bool{i == 0}
+	}
+	sr.Upper[0][0] = false
+
+	tr := TextReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	txt := tr.PrintSLOsText()
+	assert.Contains(t, txt, "http/error-rate <= 0            | insufficient data")
+	assert.Contains(t, txt, "http/latency-mean (msec) <= 500 | true")
+
+	hr := HTMLReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	var b bytes.Buffer
+	err = hr.Gen(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "insufficient data")
+}
+
+func TestReportSLOOverrides(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	_ = copyFileToPwd(t, base.CompletePath("../../", "testdata/assertinputs/experiment.yaml"))
+
+	fd := driver.FileDriver{
+		RunDir: ".",
+	}
+	exp, err := base.BuildExperiment(&fd)
+	assert.NoError(t, err)
+	in := exp.Result.Insights
+	in.VersionNames = []base.VersionInfo{{Version: "v1", Track: "shadow"}}
+	in.SLOs.Upper[0].Overrides = map[string]float64{"shadow": 0.5}
+
+	tr := TextReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	txt := tr.PrintSLOsText()
+	assert.Contains(t, txt, "http/error-rate <= 0            | true (limit 0.5)")
+	assert.Contains(t, txt, "http/latency-mean (msec) <= 500 | true\n")
+
+	hr := HTMLReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	var b bytes.Buffer
+	err = hr.Gen(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), "(limit 0.5)")
+}
+
+func TestBestVersions(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	p, q := 0.002, 0.4
+	in := &base.Insights{
+		NumVersions: 2,
+		Rewards: &base.Rewards{
+			Max: []string{"a/b", "a/c"},
+			Min: []string{"a/d"},
+		},
+		RewardsWinners: &base.RewardsWinners{
+			Max: []int{1, -1},
+			Min: []int{-1},
+			MaxStats: []*base.RewardStats{{
+				Test:    base.WelchTest,
+				PValues: []*float64{&p, nil},
+			}, nil},
+			MinStats: []*base.RewardStats{{
+				Test:    base.TwoProportionTest,
+				PValues: []*float64{nil, &q},
+			}},
+		},
+	}
+	r := &Reporter{}
+	assert.Equal(t, []string{"version 1 (p=0.002)", "insufficient data", "not significant", "n/a"},
+		r.GetBestVersions([]string{"a/b", "a/c", "a/d", "a/e"}, in))
+}
+
+func TestReportScores(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	score := 50.0
+	units := "msec"
+	exp := &base.Experiment{
+		Spec: base.ExperimentSpec{},
+		Result: &base.ExperimentResult{
+			Insights: &base.Insights{
+				NumVersions: 2,
+				MetricsInfo: map[string]base.MetricMeta{
+					"a/latency": {Type: base.GaugeMetricType, Units: &units},
+				},
+				NonHistMetricValues: []map[string][]float64{
+					{"a/latency": {10}},
+					{"a/latency": {20}},
+				},
+				HistMetricValues:    []map[string][]base.HistBucket{{}, {}},
+				SummaryMetricValues: []map[string]summarymetrics.SummaryMetric{{}, {}},
+				CanaryScores: &base.CanaryScores{
+					Baseline: 0,
+					Metrics:  []string{"a/latency", "a/errors"},
+					Classifications: [][]base.Classification{
+						{"", base.HighClassification},
+						{"", base.NoDataClassification},
+					},
+					Scores: []*float64{nil, &score},
+				},
+			},
+		},
+	}
+
+	tr := TextReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	txt := tr.PrintScoresText()
+	assert.Contains(t, txt, "a/latency (msec) | high")
+	assert.Contains(t, txt, "a/errors         | nodata")
+	assert.Contains(t, txt, "Score            | 50.00")
+	assert.Equal(t, "unavailable", tr.ScoreStr(0))
+	// no score for a version detected after the scores were computed
+	assert.Equal(t, "unavailable", tr.ScoreStr(2))
+	err := tr.Gen(os.Stdout)
+	assert.NoError(t, err)
+
+	hr := HTMLReporter{
+		Reporter: &Reporter{
+			Experiment: exp,
+		},
+	}
+	err = hr.Gen(os.Stdout)
+	assert.NoError(t, err)
+}
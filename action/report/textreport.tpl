@@ -8,6 +8,14 @@ Experiment summary:
   Number of completed tasks: {{ .Result.NumCompletedTasks }}
   Number of completed loops: {{ .Result.NumLoops }}
 
+{{- if .Result.TaskResults }}
+
+Task execution:
+***************
+
+{{ .PrintTaskResultsText | indent 2 }}
+{{- end }}
+
 {{- if .Result.Insights }}
 {{- if not (empty .Result.Insights.SLOs) }}
 
@@ -17,6 +25,16 @@ Whether or not service level objectives (SLOs) are satisfied:
 {{ .PrintSLOsText | indent 2 }}
 {{- end }}
 
+{{- if .Result.Insights.CanaryScores }}
+
+Canary scores:
+**************
+
+  Baseline: {{ .Result.Insights.TrackVersionStr .Result.Insights.CanaryScores.Baseline }}
+
+{{ .PrintScoresText | indent 2 }}
+{{- end }}
+
 Latest observed values for metrics:
 ***********************************
 
@@ -73,10 +73,10 @@ func (tr *TextReporter) getSLOStrText(i int, upper bool) (string, error) {
 	}
 	// add upper limit
 	if upper {
-		str = fmt.Sprintf("%v <= %v", str, slo.Limit)
+		str = fmt.Sprintf("%v <= %v", str, in.SLOLimitStr(i, true))
 	} else {
 		// add lower limit
-		str = fmt.Sprintf("%v <= %v", slo.Limit, str)
+		str = fmt.Sprintf("%v <= %v", in.SLOLimitStr(i, false), str)
 	}
 	return str, nil
 }
@@ -113,7 +113,7 @@ func (tr *TextReporter) printSLOsText(w *tabwriter.Writer) {
 			if err == nil {
 				fmt.Fprint(w, str)
 				for j := 0; j < in.NumVersions; j++ {
-					fmt.Fprintf(w, "\t %v", in.SLOsSatisfied.Upper[i][j])
+					fmt.Fprintf(w, "\t %v", tr.SLOSatisfiedStr(true, i, j))
 				}
 				fmt.Fprintln(w)
 			} else {
@@ -128,7 +128,7 @@ func (tr *TextReporter) printSLOsText(w *tabwriter.Writer) {
 			if err == nil {
 				fmt.Fprint(w, str)
 				for j := 0; j < in.NumVersions; j++ {
-					fmt.Fprintf(w, "\t %v", in.SLOsSatisfied.Lower[i][j])
+					fmt.Fprintf(w, "\t %v", tr.SLOSatisfiedStr(false, i, j))
 				}
 				fmt.Fprintln(w)
 			} else {
@@ -140,6 +140,72 @@ func (tr *TextReporter) printSLOsText(w *tabwriter.Writer) {
 	_ = w.Flush()
 }
 
+// PrintScoresText returns canary scores section of the text report as a string
+func (tr *TextReporter) PrintScoresText() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', tabwriter.Debug)
+	tr.printScoresText(w)
+	return b.String()
+}
+
+// printScoresText prints the classification of each metric and the score of each candidate version into tab writer
+func (tr *TextReporter) printScoresText(w *tabwriter.Writer) {
+	in := tr.Result.Insights
+	cs := in.CanaryScores
+	fmt.Fprint(w, "Metric")
+	for j := 0; j < in.NumVersions; j++ {
+		if j != cs.Baseline {
+			fmt.Fprintf(w, "\t %s", in.TrackVersionStr(j))
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprint(w, "-------")
+	for j := 0; j < in.NumVersions-1; j++ {
+		fmt.Fprint(w, "\t -----")
+	}
+	fmt.Fprintln(w)
+
+	for i, m := range cs.Metrics {
+		mwu, err := tr.MetricWithUnits(m)
+		if err != nil {
+			mwu = m
+		}
+		fmt.Fprint(w, mwu)
+		for j := 0; j < in.NumVersions; j++ {
+			if j != cs.Baseline {
+				fmt.Fprintf(w, "\t %v", cs.Classifications[i][j])
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprint(w, "Score")
+	for j := 0; j < in.NumVersions; j++ {
+		if j != cs.Baseline {
+			fmt.Fprintf(w, "\t %v", tr.ScoreStr(j))
+		}
+	}
+	fmt.Fprintln(w)
+	_ = w.Flush()
+}
+
+// PrintTaskResultsText returns task execution section of the text report as a string
+func (tr *TextReporter) PrintTaskResultsText() string {
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 0, 1, ' ', tabwriter.Debug)
+	tr.printTaskResultsText(w)
+	return b.String()
+}
+
+// printTaskResultsText prints task execution records into tab writer
+func (tr *TextReporter) printTaskResultsText(w *tabwriter.Writer) {
+	fmt.Fprintln(w, "Task\t Status\t Attempts\t Duration\t Error")
+	fmt.Fprintln(w, "----\t ------\t --------\t --------\t -----")
+	for _, r := range tr.Result.TaskResults {
+		fmt.Fprintf(w, "%v\t %v\t %v\t %v\t %v\n", r.Name, r.Status, r.Attempts, r.Duration, r.Error)
+	}
+	_ = w.Flush()
+}
+
 // PrintMetricsText returns metrics section of the text report as a string
 func (tr *TextReporter) PrintMetricsText() string {
 	var b bytes.Buffer
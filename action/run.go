@@ -1,8 +1,14 @@
 package action
 
 import (
+	"context"
+	"fmt"
+	"io"
+
 	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
 	"github.com/iter8-tools/iter8/driver"
+	"sigs.k8s.io/yaml"
 )
 
 // RunOpts are the options used for running an experiment
@@ -16,6 +22,14 @@ type RunOpts struct {
 	// ReuseResult configures Iter8 to reuse the experiment result instead of
 	// creating a new one for looping experiments.
 	ReuseResult bool
+
+	// Resume configures Iter8 to resume the experiment from its first incomplete
+	// or failed task instead of running all tasks again.
+	Resume bool
+
+	// Plan configures Iter8 to print what each task of the experiment would do
+	// instead of running the experiment.
+	Plan bool
 }
 
 // NewRunOpts initializes and returns run opts
@@ -27,10 +41,34 @@ func NewRunOpts(kd *driver.KubeDriver) *RunOpts {
 }
 
 // KubeRun runs a Kubernetes experiment
-func (rOpts *RunOpts) KubeRun() error {
+// The experiment is interrupted if ctx is done before it completes
+func (rOpts *RunOpts) KubeRun(ctx context.Context) error {
+	// initialize kube driver
+	if err := rOpts.KubeDriver.InitKube(); err != nil {
+		return err
+	}
+	if rOpts.Resume {
+		return base.ResumeExperiment(ctx, rOpts.KubeDriver)
+	}
+	return base.RunExperiment(ctx, rOpts.ReuseResult, rOpts.KubeDriver)
+}
+
+// KubePlan prints the plan of a Kubernetes experiment without running it
+// The plan includes the inputs of each task after defaults are applied, and whether its condition is satisfied
+func (rOpts *RunOpts) KubePlan(ctx context.Context, out io.Writer) error {
 	// initialize kube driver
 	if err := rOpts.KubeDriver.InitKube(); err != nil {
 		return err
 	}
-	return base.RunExperiment(rOpts.ReuseResult, rOpts.KubeDriver)
+	plan, err := base.PlanExperiment(ctx, rOpts.ReuseResult, rOpts.KubeDriver)
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(plan)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to marshal experiment plan")
+		return err
+	}
+	fmt.Fprint(out, string(b))
+	return nil
 }
@@ -1,6 +1,7 @@
 package action
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -40,7 +41,7 @@ func TestKubeRun(t *testing.T) {
 		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
 	}, metav1.CreateOptions{})
 
-	err := rOpts.KubeRun()
+	err := rOpts.KubeRun(context.Background())
 	assert.NoError(t, err)
 	// sanity check -- handler was called
 	assert.True(t, verifyHandlerCalled)
@@ -52,4 +53,60 @@ func TestKubeRun(t *testing.T) {
 	assert.True(t, exp.NoFailure())
 	assert.True(t, exp.SLOs())
 	assert.Equal(t, 4, exp.Result.NumCompletedTasks)
+
+	// resuming a completed experiment does not run it again
+	verifyHandlerCalled = false
+	rOpts.Resume = true
+	err = rOpts.KubeRun(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, verifyHandlerCalled)
+	exp, err = base.BuildExperiment(rOpts.KubeDriver)
+	assert.NoError(t, err)
+	assert.True(t, exp.Completed())
+	assert.Equal(t, 1, exp.Result.NumLoops)
+}
+
+func TestKubePlan(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	// create and configure HTTP endpoint for testing
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	url := fmt.Sprintf("http://127.0.0.1:%d/get", addr.Port)
+	var verifyHandlerCalled bool
+	mux.HandleFunc("/get", base.GetTrackingHandler(&verifyHandlerCalled))
+
+	// create experiment.yaml
+	base.CreateExperimentYaml(t, base.CompletePath("../testdata", "experiment.tpl"), url, driver.ExperimentPath)
+
+	// fix rOpts
+	rOpts := NewRunOpts(driver.NewFakeKubeDriver(cli.New()))
+	rOpts.Plan = true
+
+	// read experiment from file created above
+	byteArray, _ := os.ReadFile(driver.ExperimentPath)
+	_, _ = rOpts.Clientset.CoreV1().Secrets("default").Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "default",
+		},
+		StringData: map[string]string{driver.ExperimentPath: string(byteArray)},
+	}, metav1.CreateOptions{})
+
+	var out bytes.Buffer
+	err := rOpts.KubePlan(context.Background(), &out)
+	assert.NoError(t, err)
+	// no load is generated
+	assert.False(t, verifyHandlerCalled)
+
+	// inputs are printed with defaults applied
+	assert.Contains(t, out.String(), "name: http")
+	assert.Contains(t, out.String(), "url: "+url)
+	assert.Contains(t, out.String(), "qps: 8")
+	assert.Contains(t, out.String(), "connections: 4")
+	assert.Contains(t, out.String(), "name: assess")
+
+	// nothing is written
+	exp, err := base.BuildExperiment(rOpts.KubeDriver)
+	assert.NoError(t, err)
+	assert.True(t, exp.Result == nil || exp.Result.NumLoops == 0)
 }
@@ -0,0 +1,72 @@
+package action
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/iter8-tools/iter8/driver"
+)
+
+// ValidateOpts are the options used for validating experiment specs
+type ValidateOpts struct {
+	// File is the experiment YAML file to be validated
+	File string
+	// Schema indicates that the JSON Schema of experiment specs should be printed instead
+	Schema bool
+}
+
+// NewValidateOpts initializes and returns validate opts
+func NewValidateOpts() *ValidateOpts {
+	return &ValidateOpts{
+		File: driver.ExperimentPath,
+	}
+}
+
+// Run validates the experiment spec in the file and prints all problems found
+// It returns true if the experiment spec is valid
+func (vOpts *ValidateOpts) Run(out io.Writer) (bool, error) {
+	if vOpts.Schema {
+		s, err := base.ExperimentSchema()
+		if err != nil {
+			log.Logger.Error("unable to generate experiment schema")
+			return false, err
+		}
+		fmt.Fprintln(out, string(s))
+		return true, nil
+	}
+
+	b, err := os.ReadFile(filepath.Clean(vOpts.File))
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to read experiment file")
+		return false, err
+	}
+
+	problems := validateExperimentBytes(b)
+	for _, p := range problems {
+		fmt.Fprintln(out, p.String())
+	}
+	if len(problems) > 0 {
+		return false, nil
+	}
+	fmt.Fprintln(out, "experiment spec is valid")
+	return true, nil
+}
+
+// validateExperimentBytes returns all the problems found in an experiment YAML
+func validateExperimentBytes(b []byte) []base.SpecProblem {
+	problems := base.CheckExperimentYAML(b)
+
+	exp, err := driver.ExperimentFromBytes(b)
+	if err != nil {
+		// structural problems usually explain why the experiment cannot be read
+		if len(problems) == 0 {
+			problems = append(problems, base.SpecProblem{Message: err.Error()})
+		}
+		return problems
+	}
+	return append(problems, exp.Spec.Validate()...)
+}
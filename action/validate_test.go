@@ -0,0 +1,86 @@
+package action
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	// experiments used elsewhere in tests are valid
+	for _, f := range []string{"experiment.yaml", "experiment_db.yaml", "assertinputs/experiment.yaml"} {
+		vOpts := NewValidateOpts()
+		vOpts.File = base.CompletePath("../testdata", f)
+		var out bytes.Buffer
+		valid, err := vOpts.Run(&out)
+		assert.NoError(t, err)
+		assert.True(t, valid, f+": "+out.String())
+	}
+
+	// misspelled inputs are reported
+	vOpts := NewValidateOpts()
+	vOpts.File = base.CompletePath("../testdata", "experiment_grpc.yaml")
+	var out bytes.Buffer
+	valid, err := vOpts.Run(&out)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, `spec[0].with.connect-timeeout: unknown field
+spec[0].with.protoURL: unknown field
+`, out.String())
+}
+
+func TestValidateInvalid(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	err := os.WriteFile("experiment.yaml", []byte(`
+spec:
+- task: http
+  with:
+    qsp: 8
+- task: assess
+  with:
+    SLOs:
+      upper:
+      - limit: 10
+`), 0600)
+	assert.NoError(t, err)
+
+	vOpts := NewValidateOpts()
+	var out bytes.Buffer
+	valid, err := vOpts.Run(&out)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, `spec[0].with.qsp: unknown field
+spec[0].with.url: url is required
+spec[1].with.SLOs.upper[0].metric: metric is required
+`, out.String())
+
+	// spec that cannot be read
+	err = os.WriteFile("experiment.yaml", []byte(`
+spec:
+- task: nosuchtask
+`), 0600)
+	assert.NoError(t, err)
+	out.Reset()
+	valid, err = vOpts.Run(&out)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, "spec[0].task: unknown task nosuchtask\n", out.String())
+
+	// missing file
+	vOpts.File = "nosuchfile.yaml"
+	_, err = vOpts.Run(&out)
+	assert.Error(t, err)
+}
+
+func TestValidateSchema(t *testing.T) {
+	vOpts := NewValidateOpts()
+	vOpts.Schema = true
+	var out bytes.Buffer
+	valid, err := vOpts.Run(&out)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Contains(t, out.String(), "\"$schema\"")
+}
@@ -15,7 +15,7 @@ import (
 
 // abnClientInterface is interface for calling gRPC services
 type abnClientInterface interface {
-	callGetApplicationJSON(appName string) (string, error)
+	callGetApplicationJSON(ctx context.Context, appName string) (string, error)
 }
 
 // defaultABNClient is default implementation of interface that calls the service
@@ -23,7 +23,7 @@ type defaultABNClient struct {
 	endpoint string
 }
 
-func (wc *defaultABNClient) callGetApplicationJSON(appName string) (string, error) {
+func (wc *defaultABNClient) callGetApplicationJSON(ctx context.Context, appName string) (string, error) {
 	// setup client
 	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
 	conn, err := grpc.Dial(wc.endpoint, opts...)
@@ -33,7 +33,7 @@ func (wc *defaultABNClient) callGetApplicationJSON(appName string) (string, erro
 	c := pb.NewABNClient(conn)
 
 	// get application
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	s, err := c.GetApplicationData(
@@ -71,8 +71,8 @@ type collectABNMetricsTask struct {
 	client abnClientInterface
 }
 
-// initializeDefaults sets default values for the task
-func (t *collectABNMetricsTask) initializeDefaults() {
+// InitializeDefaults sets default values for the task
+func (t *collectABNMetricsTask) InitializeDefaults() {
 	if t.client == nil {
 		t.client = &defaultABNClient{
 			endpoint: *t.With.Endpoint,
@@ -80,26 +80,34 @@ func (t *collectABNMetricsTask) initializeDefaults() {
 	}
 }
 
-// validate task inputs
-func (t *collectABNMetricsTask) validateInputs() error {
-	return nil
+// ValidateInputs validates task inputs
+func (t *collectABNMetricsTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if len(t.With.Application) == 0 {
+		errs.add("with.application", "application is required")
+	}
+	// the endpoint is needed to create the default client
+	if t.client == nil && (t.With.Endpoint == nil || len(*t.With.Endpoint) == 0) {
+		errs.add("with.endpoint", "endpoint is required")
+	}
+	return errs.errOrNil()
 }
 
-// run exeuctes this task
-func (t *collectABNMetricsTask) run(exp *Experiment) error {
+// Run executes this task
+func (t *collectABNMetricsTask) Run(ctx context.Context, exp *Experiment) error {
 	var err error
 
 	// validate inputs
-	err = t.validateInputs()
+	err = t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
 	// initialize defaults
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	// get application json from abn service
-	applicationJSON, err := t.client.callGetApplicationJSON(t.With.Application)
+	applicationJSON, err := t.client.callGetApplicationJSON(ctx, t.With.Application)
 	if err != nil {
 		return err
 	}
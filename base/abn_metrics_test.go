@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,7 +14,7 @@ type mockABNClient struct {
 	response []byte
 }
 
-func (c *mockABNClient) callGetApplicationJSON(appName string) (string, error) {
+func (c *mockABNClient) callGetApplicationJSON(ctx context.Context, appName string) (string, error) {
 	return string(c.response), nil
 }
 
@@ -39,7 +40,7 @@ func TestABNMetricsTask(t *testing.T) {
 	}
 	exp.initResults(1)
 
-	err = task.run(exp)
+	err = task.Run(context.Background(), exp)
 	assert.NoError(t, err)
 
 	assertCount(t, exp.Result.Insights, "default", float64(223))
@@ -1,7 +1,9 @@
 package base
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/iter8-tools/iter8/base/log"
 )
@@ -13,6 +15,20 @@ type assessInputs struct {
 
 	// SLOs are the SLO limits
 	SLOs *SLOLimits `json:"SLOs,omitempty" yaml:"SLOs,omitempty"`
+
+	// Sequential enables sequential testing of the reward metrics across experiment loops
+	// The outcome is recorded as the decision in the experiment result
+	Sequential *SequentialTest `json:"sequential,omitempty" yaml:"sequential,omitempty"`
+
+	// Score enables scoring of candidate versions from weighted comparisons of metrics with a baseline
+	Score *CanaryScore `json:"score,omitempty" yaml:"score,omitempty"`
+
+	// SampleSize specifies how much data a version needs before its SLOs are evaluated
+	SampleSize *SampleSize `json:"sampleSize,omitempty" yaml:"sampleSize,omitempty"`
+
+	// Regression enables the detection of regressions compared with earlier runs of the experiment
+	// Completed runs of the experiment are recorded in the history kept by the driver
+	Regression *Regression `json:"regression,omitempty" yaml:"regression,omitempty"`
 }
 
 // assessTask enables assessment of versions
@@ -28,32 +44,82 @@ const (
 	AssessTaskName = "assess"
 )
 
-// initializeDefaults sets default values for task inputs
-func (t *assessTask) initializeDefaults() {}
+// InitializeDefaults sets default values for task inputs
+func (t *assessTask) InitializeDefaults() {}
 
-// validateInputs for this task
-func (t *assessTask) validateInputs() error {
-	return nil
+// ValidateInputs for this task
+func (t *assessTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if t.With.SLOs != nil {
+		if t.With.SLOs.Baseline < 0 {
+			errs.add("with.SLOs.baseline", "baseline must be a version index")
+		}
+		validateSLOs(&errs, "with.SLOs.upper", t.With.SLOs.Upper)
+		validateSLOs(&errs, "with.SLOs.lower", t.With.SLOs.Lower)
+	}
+	if t.With.Rewards != nil {
+		for i, m := range t.With.Rewards.Max {
+			if len(m) == 0 {
+				errs.add(fmt.Sprintf("with.rewards.max[%v]", i), "metric is required")
+			}
+		}
+		for i, m := range t.With.Rewards.Min {
+			if len(m) == 0 {
+				errs.add(fmt.Sprintf("with.rewards.min[%v]", i), "metric is required")
+			}
+		}
+		if c := t.With.Rewards.Confidence; c != nil && (*c <= 0 || *c >= 1) {
+			errs.add("with.rewards.confidence", "confidence must be between 0 and 1")
+		}
+	}
+	if t.With.Sequential != nil {
+		if t.With.Rewards == nil {
+			errs.add("with.sequential", "sequential testing requires rewards")
+		}
+		t.With.Sequential.validate(&errs, "with.sequential")
+	}
+	if t.With.Score != nil {
+		t.With.Score.validate(&errs, "with.score")
+	}
+	if t.With.SampleSize != nil {
+		t.With.SampleSize.validate(&errs, "with.sampleSize")
+	}
+	if t.With.Regression != nil {
+		t.With.Regression.validate(&errs, "with.regression")
+	}
+	return errs.errOrNil()
+}
+
+// validateSLOs validates a list of SLOs located at the given path
+func validateSLOs(errs *inputErrors, path string, slos []SLO) {
+	for i, slo := range slos {
+		if len(slo.Metric) == 0 {
+			errs.add(fmt.Sprintf("%v[%v].metric", path, i), "metric is required")
+		}
+		if slo.Ratio != nil && slo.Delta != nil {
+			errs.add(fmt.Sprintf("%v[%v]", path, i), "specify either ratio or delta but not both")
+		}
+		if _, ok := slo.Overrides[""]; ok {
+			errs.add(fmt.Sprintf("%v[%v].overrides", path, i), "overrides must be keyed by a track or version name")
+		}
+	}
 }
 
 // Run executes the assess-app-versions task
-func (t *assessTask) run(exp *Experiment) error {
-	err := t.validateInputs()
+func (t *assessTask) Run(ctx context.Context, exp *Experiment) error {
+	err := t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	if exp.Result.Insights == nil {
 		log.Logger.Error("uninitialized insights within experiment")
 		return errors.New("uninitialized insights within experiment")
 	}
-	if t.With.SLOs == nil ||
+	if (t.With.SLOs == nil && t.With.Rewards == nil && t.With.Score == nil && t.With.Regression == nil) ||
 		exp.Result.Insights.NumVersions == 0 {
-		// do nothing for now
-		// todo: fix when rewards are introduced
-
 		log.Logger.Warn("nothing to do; returning")
 		return nil
 	}
@@ -78,31 +144,94 @@ func (t *assessTask) run(exp *Experiment) error {
 
 	// set SLOsSatisfied
 	if t.With.SLOs != nil {
-		exp.Result.Insights.SLOsSatisfied = &SLOResults{
-			Upper: evaluateSLOs(exp, t.With.SLOs.Upper, true),
-			Lower: evaluateSLOs(exp, t.With.SLOs.Lower, false),
+		if t.With.SLOs.Baseline >= exp.Result.Insights.NumVersions {
+			e := fmt.Errorf("baseline version %v is out of range; experiment has %v versions", t.With.SLOs.Baseline, exp.Result.Insights.NumVersions)
+			log.Logger.Error(e)
+			return e
+		}
+		limits := &EffectiveLimits{
+			Upper: effectiveLimits(exp, t.With.SLOs.Upper, t.With.SLOs.Baseline),
+			Lower: effectiveLimits(exp, t.With.SLOs.Lower, t.With.SLOs.Baseline),
+		}
+		limits.VersionUpper = versionLimits(exp, t.With.SLOs.Upper, limits.Upper)
+		limits.VersionLower = versionLimits(exp, t.With.SLOs.Lower, limits.Lower)
+		exp.Result.Insights.EffectiveSLOLimits = limits
+		sr := &SLOResults{
+			Upper: evaluateSLOs(exp, t.With.SLOs.Upper, limits.VersionUpper, true),
+			Lower: evaluateSLOs(exp, t.With.SLOs.Lower, limits.VersionLower, false),
+		}
+		// versions with insufficient data do not satisfy SLOs yet
+		if t.With.SampleSize != nil {
+			sr.InsufficientUpper = evaluateSampleSize(exp, t.With.SampleSize, t.With.SLOs.Upper, limits.VersionUpper)
+			sr.InsufficientLower = evaluateSampleSize(exp, t.With.SampleSize, t.With.SLOs.Lower, limits.VersionLower)
+			for i := range sr.Upper {
+				for j := range sr.Upper[i] {
+					sr.Upper[i][j] = sr.Upper[i][j] && !sr.InsufficientUpper[i][j]
+				}
+			}
+			for i := range sr.Lower {
+				for j := range sr.Lower[i] {
+					sr.Lower[i][j] = sr.Lower[i][j] && !sr.InsufficientLower[i][j]
+				}
+			}
 		}
+		exp.Result.Insights.SLOsSatisfied = sr
 	}
 
 	// set RewardsWinners
 	if t.With.Rewards != nil {
-		exp.Result.Insights.RewardsWinners = &RewardsWinners{
-			Max: evaluateRewards(exp, t.With.Rewards.Max, true),
-			Min: evaluateRewards(exp, t.With.Rewards.Min, false),
+		confidence := defaultRewardConfidence
+		if t.With.Rewards.Confidence != nil {
+			confidence = *t.With.Rewards.Confidence
 		}
+		rw := &RewardsWinners{}
+		rw.Max, rw.MaxStats = evaluateRewards(exp, t.With.Rewards.Max, true, confidence)
+		rw.Min, rw.MinStats = evaluateRewards(exp, t.With.Rewards.Min, false, confidence)
+		exp.Result.Insights.RewardsWinners = rw
+	}
+
+	// set CanaryScores
+	if t.With.Score != nil {
+		var scores *CanaryScores
+		if scores, err = evaluateScore(exp, t.With.Score); err != nil {
+			return err
+		}
+		exp.Result.Insights.CanaryScores = scores
+	}
+
+	// set Regressions
+	if t.With.Regression != nil {
+		var h *History
+		if h, err = exp.readHistory(); err != nil {
+			return err
+		}
+		exp.Result.Insights.Regressions = evaluateRegression(exp, t.With.Regression, h)
+	}
+
+	// update sequential tests
+	if t.With.Sequential != nil {
+		err = evaluateSequential(exp, t.With.Sequential, t.With.Rewards)
 	}
 
 	return err
 }
 
-func evaluateRewards(exp *Experiment, rewards []string, max bool) []int {
+// evaluateRewards identifies the winner of each reward metric
+// for reward metrics that support significance tests, a winner is declared only at the given confidence level,
+// and the statistics used to decide are returned; stats are nil if no reward metric supports significance tests
+func evaluateRewards(exp *Experiment, rewards []string, max bool, confidence float64) ([]int, []*RewardStats) {
 	winners := make([]int, len(rewards))
+	rewardStats := make([]*RewardStats, len(rewards))
+	tested := false
 	for i := 0; i < len(rewards); i++ {
-		for j := 0; j < exp.Result.Insights.NumVersions; j++ {
-			winners[i] = identifyWinner(exp, rewards[i], max)
-		}
+		best := identifyWinner(exp, rewards[i], max)
+		winners[i], rewardStats[i] = getRewardStats(exp.Result.Insights, rewards[i], best, confidence)
+		tested = tested || rewardStats[i] != nil
 	}
-	return winners
+	if !tested {
+		return winners, nil
+	}
+	return winners, rewardStats
 }
 
 func identifyWinner(e *Experiment, reward string, max bool) int {
@@ -124,20 +253,67 @@ func identifyWinner(e *Experiment, reward string, max bool) int {
 	return currentWinner
 }
 
+// effectiveLimits computes the limit of each SLO
+// relative limits are computed from the value of the metric for the baseline version
+func effectiveLimits(exp *Experiment, slos []SLO, baseline int) []*float64 {
+	limits := make([]*float64, len(slos))
+	for i, slo := range slos {
+		if !slo.relative() {
+			limits[i] = float64Pointer(slo.Limit)
+			continue
+		}
+		val := exp.Result.Insights.ScalarMetricValue(baseline, slo.Metric)
+		if val == nil {
+			log.Logger.Warnf("unable to find value for baseline version %v and metric %s", baseline, slo.Metric)
+			continue
+		}
+		if slo.Ratio != nil {
+			limits[i] = float64Pointer(*slo.Ratio * *val)
+		} else {
+			limits[i] = float64Pointer(*val + *slo.Delta)
+		}
+	}
+	return limits
+}
+
+// versionLimits computes the limit of each SLO for each version
+// limits for versions whose track or version name is overridden by an SLO replace the effective limit of the SLO
+func versionLimits(exp *Experiment, slos []SLO, limits []*float64) [][]*float64 {
+	in := exp.Result.Insights
+	vl := make([][]*float64, len(slos))
+	for i, slo := range slos {
+		vl[i] = make([]*float64, in.NumVersions)
+		for j := 0; j < in.NumVersions; j++ {
+			if l, ok := in.overrideFor(slo, j); ok {
+				vl[i][j] = float64Pointer(l)
+			} else {
+				vl[i][j] = limits[i]
+			}
+		}
+	}
+	return vl
+}
+
 // evaluate SLOs and output the boolean SLO X version matrix
-func evaluateSLOs(exp *Experiment, slos []SLO, upper bool) [][]bool {
+// limits[i][j] is the limit of SLO i for version j
+func evaluateSLOs(exp *Experiment, slos []SLO, limits [][]*float64, upper bool) [][]bool {
 	slosSatisfied := make([][]bool, len(slos))
 	for i := 0; i < len(slos); i++ {
 		slosSatisfied[i] = make([]bool, exp.Result.Insights.NumVersions)
 		for j := 0; j < exp.Result.Insights.NumVersions; j++ {
-			slosSatisfied[i][j] = sloSatisfied(exp, slos, i, j, upper)
+			slosSatisfied[i][j] = sloSatisfied(exp, slos, limits, i, j, upper)
 		}
 	}
 	return slosSatisfied
 }
 
 // sloSatisfied returns true if SLO i satisfied by version j
-func sloSatisfied(e *Experiment, slos []SLO, i int, j int, upper bool) bool {
+func sloSatisfied(e *Experiment, slos []SLO, limits [][]*float64, i int, j int, upper bool) bool {
+	// check if limit is available
+	limit := limits[i][j]
+	if limit == nil {
+		return false
+	}
 	val := e.Result.Insights.ScalarMetricValue(j, slos[i].Metric)
 	// check if metric is available
 	if val == nil {
@@ -147,12 +323,12 @@ func sloSatisfied(e *Experiment, slos []SLO, i int, j int, upper bool) bool {
 
 	if upper {
 		// check upper limit
-		if *val > slos[i].Limit {
+		if *val > *limit {
 			return false
 		}
 	} else {
 		// check lower limit
-		if *val < slos[i].Limit {
+		if *val < *limit {
 			return false
 		}
 	}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -23,7 +24,7 @@ func TestRunAssess(t *testing.T) {
 	}
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
-	err := task.run(exp)
+	err := task.Run(context.Background(), exp)
 	assert.NoError(t, err)
 
 	// assess with an SLO
@@ -39,6 +40,134 @@ func TestRunAssess(t *testing.T) {
 			Max: []string{"a/b"},
 		},
 	}
-	err = task.run(exp)
+	err = task.Run(context.Background(), exp)
 	assert.NoError(t, err)
 }
+
+func TestRunAssessRelativeSLOs(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			SLOs: &SLOLimits{
+				Baseline: 1,
+				Upper: []SLO{{
+					Metric: "a/latency",
+					Ratio:  float64Pointer(1.1),
+				}, {
+					Metric: "a/error-rate",
+					Delta:  float64Pointer(0.5),
+				}, {
+					Metric: "a/latency",
+					Limit:  150,
+				}},
+				Lower: []SLO{{
+					Metric: "a/missing",
+					Ratio:  float64Pointer(0.9),
+				}},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(3)
+	in := exp.Result.Insights
+	for i, v := range []float64{120, 100, 105} {
+		assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, i, v))
+	}
+	for i, v := range []float64{1.2, 1, 1.6} {
+		assert.NoError(t, in.updateMetric("a/error-rate", MetricMeta{Type: GaugeMetricType}, i, v))
+	}
+
+	err := task.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	// effective limits are computed from the baseline version
+	assert.Equal(t, 3, len(in.EffectiveSLOLimits.Upper))
+	assert.InDelta(t, 110, *in.EffectiveSLOLimits.Upper[0], 1e-9)
+	assert.InDelta(t, 1.5, *in.EffectiveSLOLimits.Upper[1], 1e-9)
+	assert.Equal(t, 150.0, *in.EffectiveSLOLimits.Upper[2])
+	assert.Nil(t, in.EffectiveSLOLimits.Lower[0])
+
+	assert.Equal(t, []bool{false, true, true}, in.SLOsSatisfied.Upper[0])
+	assert.Equal(t, []bool{true, true, false}, in.SLOsSatisfied.Upper[1])
+	assert.Equal(t, []bool{true, true, true}, in.SLOsSatisfied.Upper[2])
+	assert.Equal(t, []bool{false, false, false}, in.SLOsSatisfied.Lower[0])
+	assert.Equal(t, []int{}, exp.getSLOsSatisfiedBy())
+
+	assert.Equal(t, "1.1 x version 1 (110.00)", in.SLOLimitStr(0, true))
+	assert.Equal(t, "version 1 + 0.5 (1.50)", in.SLOLimitStr(1, true))
+	assert.Equal(t, "150", in.SLOLimitStr(2, true))
+	assert.Equal(t, "0.9 x version 1", in.SLOLimitStr(0, false))
+
+	// baseline must be one of the versions
+	task.With.SLOs.Baseline = 3
+	exp.Result.Insights.SLOs = nil
+	err = task.Run(context.Background(), exp)
+	assert.Error(t, err)
+
+	// ratio and delta cannot both be specified
+	task.With.SLOs.Baseline = 0
+	task.With.SLOs.Upper[0].Delta = float64Pointer(1)
+	err = task.ValidateInputs()
+	assert.Error(t, err)
+}
+
+func TestRunAssessSLOOverrides(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			SLOs: &SLOLimits{
+				Upper: []SLO{{
+					Metric:    "a/latency",
+					Limit:     100,
+					Overrides: map[string]float64{"shadow": 200, "v3": 50},
+				}, {
+					Metric:    "a/latency",
+					Ratio:     float64Pointer(1.1),
+					Overrides: map[string]float64{"shadow": 150},
+				}},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(3)
+	in := exp.Result.Insights
+	in.VersionNames = []VersionInfo{
+		{Version: "v1", Track: "primary"},
+		{Version: "v2", Track: "shadow"},
+		{Version: "v3", Track: "canary"},
+	}
+	for i, v := range []float64{90, 140, 60} {
+		assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, i, v))
+	}
+
+	err := task.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	// overrides apply to versions by track or version name
+	assert.Equal(t, 100.0, *in.EffectiveSLOLimits.Upper[0])
+	assert.Equal(t, []*float64{float64Pointer(100), float64Pointer(200), float64Pointer(50)}, in.EffectiveSLOLimits.VersionUpper[0])
+	assert.InDelta(t, 99, *in.EffectiveSLOLimits.VersionUpper[1][2], 1e-9)
+	assert.Equal(t, 150.0, *in.EffectiveSLOLimits.VersionUpper[1][1])
+	assert.Equal(t, []bool{true, true, false}, in.SLOsSatisfied.Upper[0])
+	assert.Equal(t, []bool{true, true, true}, in.SLOsSatisfied.Upper[1])
+
+	assert.Equal(t, "", in.SLOVersionLimitStr(0, 0, true))
+	assert.Equal(t, "200", in.SLOVersionLimitStr(0, 1, true))
+	assert.Equal(t, "50", in.SLOVersionLimitStr(0, 2, true))
+
+	// overrides must be keyed by a name
+	task.With.SLOs.Upper[0].Overrides = map[string]float64{"": 10}
+	assert.Error(t, task.ValidateInputs())
+}
@@ -1,7 +1,9 @@
 package base
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bojand/ghz/runner"
@@ -49,8 +51,8 @@ type collectGRPCTask struct {
 	With collectGRPCInputs `json:"with" yaml:"with"`
 }
 
-// initializeDefaults sets default values for the collect task
-func (t *collectGRPCTask) initializeDefaults() {
+// InitializeDefaults sets default values for the collect task
+func (t *collectGRPCTask) InitializeDefaults() {
 	// set defaults
 	gd.SetDefaults(&t.With)
 	// if dial timeout is zero, then set a default...
@@ -65,13 +67,78 @@ func (t *collectGRPCTask) initializeDefaults() {
 	t.With.Insecure = insecureDefault
 }
 
-// validate task inputs
-func (t *collectGRPCTask) validateInputs() error {
-	return nil
+// ValidateInputs validates task inputs
+func (t *collectGRPCTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if len(t.With.Endpoints) == 0 {
+		if len(t.With.Call) == 0 {
+			errs.add("with.call", "call is required")
+		}
+		if len(t.With.Host) == 0 {
+			errs.add("with.host", "host is required")
+		}
+	}
+	ids := make([]string, 0, len(t.With.Endpoints))
+	for id := range t.With.Endpoints {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		e := t.With.Endpoints[id]
+		path := "with.endpoints." + id
+		// endpoints inherit call and host from the task inputs
+		if len(e.Call) == 0 && len(t.With.Call) == 0 {
+			errs.add(path+".call", "call is required")
+		}
+		if len(e.Host) == 0 && len(t.With.Host) == 0 {
+			errs.add(path+".host", "host is required")
+		}
+	}
+	return errs.errOrNil()
+}
+
+// runGRPCTest runs a ghz gRPC test which is stopped once ctx is done
+// this mirrors runner.Run, with cancellation through ctx instead of interrupt signals
+func runGRPCTest(ctx context.Context, call string, host string, cfg *runner.Config) (*runner.Report, error) {
+	c, err := runner.NewConfig(call, host, runner.WithConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	reqr, err := runner.NewRequester(c)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		var timeout <-chan time.Time
+		if cfg.Z > 0 {
+			timer := time.NewTimer(time.Duration(cfg.Z))
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			log.Logger.Warn("stopping ghz gRPC test")
+			reqr.Stop(runner.ReasonCancel)
+		case <-timeout:
+			reqr.Stop(runner.ReasonTimeout)
+		case <-done:
+		}
+	}()
+
+	rep, err := reqr.Run()
+	if err != nil {
+		return rep, err
+	}
+	// results of a stopped test are partial
+	return rep, ctx.Err()
 }
 
 // resultForVersion collects gRPC test result for a given version
-func (t *collectGRPCTask) resultForVersion() (map[string]*runner.Report, error) {
+func (t *collectGRPCTask) resultForVersion(ctx context.Context) (map[string]*runner.Report, error) {
 	// the main idea is to run ghz with proper options
 
 	var err error
@@ -99,10 +166,12 @@ func (t *collectGRPCTask) resultForVersion() (map[string]*runner.Report, error)
 				log.Logger.Error(fmt.Sprintf("could not merge Fortio options for endpoint \"%s\"", endpointID))
 				return nil, err
 			}
-			eOpts := runner.WithConfig(&endpoint) // endpoint options
 
 			log.Logger.Trace("run ghz gRPC test")
-			igr, err := runner.Run(call, host, eOpts)
+			igr, err := runGRPCTest(ctx, call, host, &endpoint)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			if err != nil {
 				log.Logger.WithStackTrace(err.Error()).Error(err)
 				continue
@@ -112,10 +181,8 @@ func (t *collectGRPCTask) resultForVersion() (map[string]*runner.Report, error)
 		}
 	} else {
 		// TODO: supply all the allowed options
-		opts := runner.WithConfig(&t.With.Config)
-
 		log.Logger.Trace("run ghz gRPC test")
-		igr, err := runner.Run(t.With.Call, t.With.Host, opts)
+		igr, err := runGRPCTest(ctx, t.With.Call, t.With.Host, &t.With.Config)
 		if err != nil {
 			log.Logger.WithStackTrace(err.Error()).Error(err)
 			return results, err
@@ -137,23 +204,23 @@ func latencySample(rd []runner.ResultDetail) []float64 {
 }
 
 // Run executes this task
-func (t *collectGRPCTask) run(exp *Experiment) error {
+func (t *collectGRPCTask) Run(ctx context.Context, exp *Experiment) error {
 	// 1. initialize defaults
 	var err error
 
-	err = t.validateInputs()
+	err = t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	// 2. collect raw results from ghz
 
 	// run ghz test
 	// collect ghz report
 	// ghz reports will be further processed to populate metrics
-	data, err := t.resultForVersion()
+	data, err := t.resultForVersion(ctx)
 	if err != nil {
 		return err
 	}
@@ -173,11 +240,12 @@ func (t *collectGRPCTask) run(exp *Experiment) error {
 	// 4. Populate all metrics collected by this task
 	for provider, data := range data {
 		// populate grpc request count
-		// todo: this logic breaks for looped experiments. Fix when we get to loops.
+		// metrics are observed within this loop; counters are summed over loops
 		m := provider + "/" + gRPCRequestCountMetricName
 		mm := MetricMeta{
 			Description: "number of gRPC requests sent",
 			Type:        CounterMetricType,
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, float64(data.Count)); err != nil {
 			return err
@@ -190,24 +258,25 @@ func (t *collectGRPCTask) run(exp *Experiment) error {
 		}
 
 		// populate count
-		// todo: This logic breaks for looped experiments. Fix when we get to loops.
 		m = provider + "/" + gRPCErrorCountMetricName
 		mm = MetricMeta{
 			Description: "number of responses that were errors",
 			Type:        CounterMetricType,
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, ec); err != nil {
 			return err
 		}
 
 		// populate rate
-		// todo: This logic breaks for looped experiments. Fix when we get to loops.
+		// the rate in this loop is recorded; the value of the metric is the rate over all loops, computed from the counts
 		m = provider + "/" + gRPCErrorRateMetricName
 		rc := float64(data.Count)
 		if rc != 0 {
 			mm = MetricMeta{
 				Description: "fraction of responses that were errors",
 				Type:        GaugeMetricType,
+				PerLoop:     true,
 			}
 			if err = in.updateMetric(m, mm, 0, ec/rc); err != nil {
 				return err
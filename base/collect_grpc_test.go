@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -53,7 +54,7 @@ func TestRunCollectGRPCUnary(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	log.Logger.Debug("dial timeout after defaulting... ", ct.With.DialTimeout.String())
 
@@ -104,7 +105,7 @@ func TestRunCollectGRPCUnaryNoEndpoint(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 
 	// Error should be a connection error, not a nil pointer dereference error
 	// Test written like this because of conversion between localhost and 127.0.0.1
@@ -159,7 +160,7 @@ func TestRunCollectGRPCEndpoints(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	log.Logger.Debug("dial timeout after defaulting... ", ct.With.DialTimeout.String())
 
@@ -229,7 +230,7 @@ func TestRunCollectGRPCMultipleNoEndpoints(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 	assert.NoError(t, err)
 
 	// No metrics should be collected
@@ -304,9 +305,9 @@ func TestMockGRPCWithSLOsAndPercentiles(t *testing.T) {
 
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
-	err = exp.Spec[0].run(exp)
+	err = exp.Spec[0].Run(context.Background(), exp)
 	assert.NoError(t, err)
-	err = exp.Spec[1].run(exp)
+	err = exp.Spec[1].Run(context.Background(), exp)
 	assert.NoError(t, err)
 
 	// assert SLOs are satisfied
@@ -1,9 +1,13 @@
 package base
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
 	"os"
+	"sort"
 	"time"
 
 	"fortio.org/fortio/fhttp"
@@ -43,12 +47,45 @@ type endpoint struct {
 	Percentiles []float64 `json:"percentiles,omitempty" yaml:"percentiles,omitempty"`
 	// HTTP headers to use in the query; optional
 	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// HTTP query parameters to use in the query; optional
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
 	// URL to use for querying the app
 	URL string `json:"url" yaml:"url"`
 	// AllowInitialErrors allows and doesn't abort on initial warmup errors
 	AllowInitialErrors *bool `json:"allowInitialErrors,omitempty" yaml:"allowInitialErrors,omitempty"`
 	// Warmup indicates if task execution is for warmup purposes; if so the results will be ignored
 	Warmup *bool `json:"warmup,omitempty" yaml:"warmup,omitempty"`
+	// CACert is the path of a PEM file with the CA certificates used to verify the app; optional. Certificates may be mounted from secrets.
+	CACert *string `json:"caCert,omitempty" yaml:"caCert,omitempty"`
+	// Cert is the path of a PEM file with the client certificate used for mutual TLS; optional. If this field is specified, `key` is required.
+	Cert *string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	// Key is the path of a PEM file with the private key of the client certificate
+	Key *string `json:"key,omitempty" yaml:"key,omitempty"`
+	// InsecureSkipVerify disables the verification of the certificate of the app
+	InsecureSkipVerify *bool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+	// Host overrides the Host header of requests, which is also the TLS server name (SNI)
+	Host *string `json:"host,omitempty" yaml:"host,omitempty"`
+	// Resolve is the IP address to which requests are sent, in place of the address of the host in the url
+	Resolve *string `json:"resolve,omitempty" yaml:"resolve,omitempty"`
+	// HTTP2 indicates if requests use HTTP/2; requests to http urls use HTTP/2 without TLS (h2c)
+	HTTP2 *bool `json:"http2,omitempty" yaml:"http2,omitempty"`
+	// DisableKeepAlive indicates if a new connection is used for each request
+	DisableKeepAlive *bool `json:"disableKeepAlive,omitempty" yaml:"disableKeepAlive,omitempty"`
+	// Timeout of each request. Specified in the Go duration string format (example, 5s). Default value is 3s.
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Checks of responses whose status codes are not errors; optional. Responses that fail a check are counted as errors, and as assertion failures.
+	Checks *responseChecks `json:"checks,omitempty" yaml:"checks,omitempty"`
+	// Dataset is a JSONL or CSV file of rows. If this field is specified, the url, headers, params and payload of each request are rendered from a row of the dataset.
+	Dataset *dataset `json:"dataset,omitempty" yaml:"dataset,omitempty"`
+	// Steps define a multi-step scenario, such as login followed by checkout. If this field is specified, the url, headers, params and payload fields above are ignored.
+	// Each request is then a run of the scenario, in which the steps are sent in order, and values extracted from responses are used by later steps.
+	// NumRequests, Duration and QPS refer to runs of the scenario, and Connections is the number of concurrent runs.
+	// Metrics of entire runs are prefixed with http (or http-<endpoint>), and metrics of each step with http-<step> (or http-<endpoint>-<step>).
+	Steps []scenarioStep `json:"steps,omitempty" yaml:"steps,omitempty"`
+	// Stages define a load profile, such as a ramp-up followed by a steady state. If this field is specified, the numRequests, duration and qps fields above are ignored.
+	// The stages run in order, and the rate of requests follows the QPS and shape of each stage. Connections should suffice for the highest rate.
+	// Metrics of each stage are prefixed with http-<stage> (or http-<endpoint>-<stage>), in addition to the metrics of the entire load test.
+	Stages []loadStage `json:"stages,omitempty" yaml:"stages,omitempty"`
 }
 
 // collectHTTPInputs contain the inputs to the metrics collection task to be executed.
@@ -79,6 +116,8 @@ const (
 	builtInHTTPLatencyMinID    = "latency-min"
 	builtInHTTPLatencyMaxID    = "latency-max"
 	builtInHTTPLatencyHistID   = "latency"
+	// the following metric is collected only if responses are checked
+	builtInHTTPAssertionFailuresID = "assertion-failures"
 	// prefix used in latency percentile metric names
 	// example: latency-p75.0 is the 75th percentile latency
 	builtInHTTPLatencyPercentilePrefix = "latency-p"
@@ -123,8 +162,8 @@ type collectHTTPTask struct {
 	With collectHTTPInputs `json:"with" yaml:"with"`
 }
 
-// initializeDefaults sets default values for the collect task
-func (t *collectHTTPTask) initializeDefaults() {
+// InitializeDefaults sets default values for the collect task
+func (t *collectHTTPTask) InitializeDefaults() {
 	if t.With.NumRequests == nil && t.With.Duration == nil {
 		t.With.NumRequests = int64Pointer(defaultHTTPNumRequests)
 	}
@@ -152,9 +191,97 @@ func (t *collectHTTPTask) initializeDefaults() {
 	}
 }
 
-// validateInputs for this task
-func (t *collectHTTPTask) validateInputs() error {
-	return nil
+// ValidateInputs for this task
+func (t *collectHTTPTask) ValidateInputs() error {
+	errs := inputErrors{}
+	validateEndpoint(&errs, "with", t.With.endpoint)
+	if len(t.With.Endpoints) == 0 {
+		if len(t.With.URL) == 0 && len(t.With.Steps) == 0 {
+			errs.add("with.url", "url is required")
+		}
+	}
+	ids := make([]string, 0, len(t.With.Endpoints))
+	for id := range t.With.Endpoints {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		e := t.With.Endpoints[id]
+		path := "with.endpoints." + id
+		validateEndpoint(&errs, path, e)
+		// endpoints inherit the url and steps from the task inputs
+		if len(e.URL) == 0 && len(t.With.URL) == 0 && len(e.Steps) == 0 && len(t.With.Steps) == 0 {
+			errs.add(path+".url", "url is required")
+		}
+	}
+	return errs.errOrNil()
+}
+
+// validateEndpoint records invalid endpoint inputs located at the given path
+func validateEndpoint(errs *inputErrors, path string, e endpoint) {
+	if e.NumRequests != nil && *e.NumRequests <= 0 {
+		errs.add(path+".numRequests", "numRequests must be positive")
+	}
+	if e.Duration != nil {
+		if d, err := time.ParseDuration(*e.Duration); err != nil {
+			errs.add(path+".duration", "invalid duration %v", *e.Duration)
+		} else if d <= 0 {
+			errs.add(path+".duration", "duration must be positive")
+		}
+	}
+	if e.QPS != nil && *e.QPS <= 0 {
+		errs.add(path+".qps", "qps must be positive")
+	}
+	if e.Connections != nil && *e.Connections <= 0 {
+		errs.add(path+".connections", "connections must be positive")
+	}
+	for i, er := range e.ErrorRanges {
+		erPath := fmt.Sprintf("%v.errorRanges[%v]", path, i)
+		if er.Lower == nil && er.Upper == nil {
+			errs.add(erPath, "error range needs a lower or an upper limit")
+		}
+		if er.Lower != nil && er.Upper != nil && *er.Lower > *er.Upper {
+			errs.add(erPath, "lower limit %v is greater than upper limit %v", *er.Lower, *er.Upper)
+		}
+	}
+	for i, p := range e.Percentiles {
+		if p <= 0 || p >= 100 {
+			errs.add(fmt.Sprintf("%v.percentiles[%v]", path, i), "percentile %v is not between 0 and 100", p)
+		}
+	}
+	if (e.Cert == nil) != (e.Key == nil) {
+		errs.add(path, "cert and key must be specified together")
+	}
+	if e.Resolve != nil && net.ParseIP(*e.Resolve) == nil {
+		errs.add(path+".resolve", "%v is not an IP address", *e.Resolve)
+	}
+	if e.Timeout != nil {
+		if d, err := time.ParseDuration(*e.Timeout); err != nil {
+			errs.add(path+".timeout", "invalid timeout %v", *e.Timeout)
+		} else if d <= 0 {
+			errs.add(path+".timeout", "timeout must be positive")
+		}
+	}
+	validateSteps(errs, path, e.Steps)
+	validateStages(errs, path, e.Stages, e.Steps)
+	if e.Checks != nil {
+		e.Checks.validate(errs, path+".checks")
+	}
+	if e.Dataset != nil {
+		e.Dataset.validate(errs, path+".dataset")
+	}
+	// requests sent by Iter8 are rendered from templates
+	if len(e.Steps) == 0 && e.customRequests() {
+		if _, err := compileStep(e.requestStep(), false); err != nil {
+			errs.add(path, "%v", err)
+		}
+	}
+}
+
+// customRequests returns true if the requests of the endpoint are sent by Iter8 rather than Fortio
+// This is the case for multi-step scenarios, requests rendered from datasets, load profiles, and checked responses
+func (e endpoint) customRequests() bool {
+	return len(e.Steps) > 0 || e.Dataset != nil || len(e.Stages) > 0 || e.Checks != nil
 }
 
 // getFortioOptions constructs Fortio's HTTP runner options based on collect task inputs
@@ -174,6 +301,21 @@ func getFortioOptions(c endpoint) (*fhttp.HTTPRunnerOptions, error) {
 		AllowInitialErrors: *c.AllowInitialErrors,
 	}
 
+	// query params
+	if len(c.Params) > 0 {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			log.Logger.WithStackTrace(err.Error()).Error("unable to parse url")
+			return nil, err
+		}
+		q := u.Query()
+		for key, value := range c.Params {
+			q.Add(key, value)
+		}
+		u.RawQuery = q.Encode()
+		fo.URL = u.String()
+	}
+
 	// num requests
 	if c.NumRequests != nil {
 		fo.RunnerOptions.Exactly = *c.NumRequests
@@ -214,18 +356,73 @@ func getFortioOptions(c endpoint) (*fhttp.HTTPRunnerOptions, error) {
 			return nil, err
 		}
 	}
+	if c.Host != nil {
+		if err = fo.AddAndValidateExtraHeader("Host:" + *c.Host); err != nil {
+			log.Logger.WithStackTrace("unable to add host header").Error(err)
+			return nil, err
+		}
+	}
+
+	// TLS and connections
+	fo.TLSOptions = c.tlsOptions()
+	if c.Resolve != nil {
+		fo.Resolve = *c.Resolve
+	}
+	if c.HTTP2 != nil {
+		fo.H2 = *c.HTTP2
+	}
+	if c.DisableKeepAlive != nil {
+		fo.DisableKeepAlive = *c.DisableKeepAlive
+	}
+	if fo.HTTPReqTimeOut, err = c.timeout(); err != nil {
+		return nil, err
+	}
 
 	return fo, nil
 }
 
+// runHTTPTest runs a Fortio HTTP test which is aborted once ctx is done
+func runHTTPTest(ctx context.Context, fo *fhttp.HTTPRunnerOptions) (*fhttp.HTTPRunnerResults, error) {
+	// Fortio resets fo.Stop when the run starts; keep our own reference to the aborter
+	aborter := periodic.NewAborter()
+	fo.Stop = aborter
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Logger.Warn("aborting fortio HTTP test")
+			aborter.Abort(false)
+		case <-done:
+		}
+	}()
+
+	ifr, err := fhttp.RunHTTPTest(fo)
+	if err != nil {
+		return ifr, err
+	}
+	// results of an aborted test are partial
+	return ifr, ctx.Err()
+}
+
+// httpResult is the result of the requests whose metrics share a prefix
+type httpResult struct {
+	*fhttp.HTTPRunnerResults
+	// assertionFailures is the number of responses that failed checks; nil if responses were not checked
+	assertionFailures *int64
+	// partial is true for the results of a step or stage of a scenario, which are also part of the results of its runs
+	partial bool
+}
+
 // getFortioResults collects Fortio run results
 // func (t *collectHTTPTask) getFortioResults() (*fhttp.HTTPRunnerResults, error) {
 // key is the metric prefix
-func (t *collectHTTPTask) getFortioResults() (map[string]*fhttp.HTTPRunnerResults, error) {
+func (t *collectHTTPTask) getFortioResults(ctx context.Context) (map[string]*httpResult, error) {
 	// the main idea is to run Fortio with proper options
 
 	var err error
-	results := map[string]*fhttp.HTTPRunnerResults{}
+	results := map[string]*httpResult{}
 	if len(t.With.Endpoints) > 0 {
 		log.Logger.Trace("multiple endpoints")
 		for endpointID, endpoint := range t.With.Endpoints {
@@ -238,6 +435,22 @@ func (t *collectHTTPTask) getFortioResults() (map[string]*fhttp.HTTPRunnerResult
 				return nil, err
 			}
 
+			if endpoint.customRequests() {
+				log.Logger.Trace("run HTTP scenario")
+				sr, err := t.runScenario(ctx, endpoint, httpMetricPrefix+"-"+endpointID)
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				if err != nil {
+					log.Logger.WithStackTrace(err.Error()).Error("HTTP scenario failed")
+					continue
+				}
+				for prefix, ifr := range sr {
+					results[prefix] = ifr
+				}
+				continue
+			}
+
 			efo, err := getFortioOptions(endpoint)
 			if err != nil {
 				log.Logger.Error(fmt.Sprintf("could not get Fortio options for endpoint \"%s\"", endpointID))
@@ -248,14 +461,25 @@ func (t *collectHTTPTask) getFortioResults() (map[string]*fhttp.HTTPRunnerResult
 			log.Logger.Trace("URL: ", efo.URL)
 
 			log.Logger.Trace("run fortio HTTP test")
-			ifr, err := fhttp.RunHTTPTest(efo)
+			ifr, err := runHTTPTest(ctx, efo)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			if err != nil {
 				log.Logger.WithStackTrace(err.Error()).Error("fortio failed")
 				continue
 			}
 
-			results[httpMetricPrefix+"-"+endpointID] = ifr
+			results[httpMetricPrefix+"-"+endpointID] = &httpResult{HTTPRunnerResults: ifr}
+		}
+	} else if t.With.customRequests() {
+		log.Logger.Trace("run HTTP scenario")
+		sr, err := t.runScenario(ctx, t.With.endpoint, httpMetricPrefix)
+		if err != nil {
+			log.Logger.WithStackTrace(err.Error()).Error("HTTP scenario failed")
+			return results, err
 		}
+		results = sr
 	} else {
 		fo, err := getFortioOptions(t.With.endpoint)
 		if err != nil {
@@ -267,29 +491,29 @@ func (t *collectHTTPTask) getFortioResults() (map[string]*fhttp.HTTPRunnerResult
 		log.Logger.Trace("URL: ", fo.URL)
 
 		log.Logger.Trace("run fortio HTTP test")
-		ifr, err := fhttp.RunHTTPTest(fo)
+		ifr, err := runHTTPTest(ctx, fo)
 		if err != nil {
 			log.Logger.WithStackTrace(err.Error()).Error("fortio failed")
 			return results, err
 		}
 
-		results[httpMetricPrefix] = ifr
+		results[httpMetricPrefix] = &httpResult{HTTPRunnerResults: ifr}
 	}
 
 	return results, err
 }
 
-// run executes this task
-func (t *collectHTTPTask) run(exp *Experiment) error {
-	err := t.validateInputs()
+// Run executes this task
+func (t *collectHTTPTask) Run(ctx context.Context, exp *Experiment) error {
+	err := t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	// run fortio
-	data, err := t.getFortioResults()
+	data, err := t.getFortioResults(ctx)
 	if err != nil {
 		return err
 	}
@@ -310,39 +534,59 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 
 	for provider, data := range data {
 		// request count
+		// metrics are observed within this loop; counters are summed over loops, and gauges describe this loop
 		m := provider + "/" + builtInHTTPRequestCountID
 		mm := MetricMeta{
 			Description: "number of requests sent",
 			Type:        CounterMetricType,
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, float64(data.DurationHistogram.Count)); err != nil {
 			return err
 		}
 
 		// error count & rate
+		// responses that failed checks are errors, though their status codes are not
 		val := float64(0)
 		for code, count := range data.RetCodes {
 			if t.errorCode(code) {
 				val += float64(count)
 			}
 		}
+		if data.assertionFailures != nil {
+			val += float64(*data.assertionFailures)
+
+			// assertion failures
+			m = provider + "/" + builtInHTTPAssertionFailuresID
+			mm = MetricMeta{
+				Description: "number of responses that failed checks",
+				Type:        CounterMetricType,
+				PerLoop:     true,
+			}
+			if err = in.updateMetric(m, mm, 0, float64(*data.assertionFailures)); err != nil {
+				return err
+			}
+		}
 		// error count
 		m = provider + "/" + builtInHTTPErrorCountID
 		mm = MetricMeta{
 			Description: "number of responses that were errors",
 			Type:        CounterMetricType,
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, val); err != nil {
 			return err
 		}
 
 		// error-rate
+		// the rate in this loop is recorded; the value of the metric is the rate over all loops, computed from the counts
 		m = provider + "/" + builtInHTTPErrorRateID
 		rc := float64(data.DurationHistogram.Count)
 		if rc != 0 {
 			mm = MetricMeta{
 				Description: "fraction of responses that were errors",
 				Type:        GaugeMetricType,
+				PerLoop:     true,
 			}
 			if err = in.updateMetric(m, mm, 0, val/rc); err != nil {
 				return err
@@ -355,6 +599,7 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 			Description: "mean of observed latency values",
 			Type:        GaugeMetricType,
 			Units:       StringPointer("msec"),
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.Avg); err != nil {
 			return err
@@ -366,6 +611,7 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 			Description: "standard deviation of observed latency values",
 			Type:        GaugeMetricType,
 			Units:       StringPointer("msec"),
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.StdDev); err != nil {
 			return err
@@ -377,6 +623,7 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 			Description: "minimum of observed latency values",
 			Type:        GaugeMetricType,
 			Units:       StringPointer("msec"),
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.Min); err != nil {
 			return err
@@ -388,6 +635,7 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 			Description: "maximum of observed latency values",
 			Type:        GaugeMetricType,
 			Units:       StringPointer("msec"),
+			PerLoop:     true,
 		}
 		if err = in.updateMetric(m, mm, 0, 1000.0*data.DurationHistogram.Max); err != nil {
 			return err
@@ -400,6 +648,7 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 				Description: fmt.Sprintf("%v-th percentile of observed latency values", p.Percentile),
 				Type:        GaugeMetricType,
 				Units:       StringPointer("msec"),
+				PerLoop:     true,
 			}
 			if err = in.updateMetric(m, mm, 0, 1000.0*p.Value); err != nil {
 				return err
@@ -407,6 +656,10 @@ func (t *collectHTTPTask) run(exp *Experiment) error {
 		}
 
 		// latency histogram
+		// results of steps and stages are left out, since their requests are already counted in the results of runs
+		if data.partial {
+			continue
+		}
 		m = httpMetricPrefix + "/" + builtInHTTPLatencyHistID
 		mm = MetricMeta{
 			Description: "Latency Histogram",
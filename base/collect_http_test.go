@@ -2,6 +2,7 @@ package base
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 
 	"fortio.org/fortio/fhttp"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -60,7 +62,7 @@ func TestRunCollectHTTP(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 	assert.NoError(t, err)
 	assert.True(t, called) // ensure that the /foo/ handler is called
 	assert.Equal(t, exp.Result.Insights.NumVersions, 1)
@@ -72,6 +74,17 @@ func TestRunCollectHTTP(t *testing.T) {
 	mm, err = exp.Result.Insights.GetMetricsInfo(httpMetricPrefix + "/" + builtInHTTPLatencyPercentilePrefix + "50")
 	assert.NotNil(t, mm)
 	assert.NoError(t, err)
+
+	// request counts are cumulative across loops
+	in := exp.Result.Insights
+	requests := httpMetricPrefix + "/" + builtInHTTPRequestCountID
+	first := *in.ScalarMetricValue(0, requests)
+	exp.Result.NumLoops++
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+	assert.Equal(t, first+*in.latestMetricValue(0, requests), *in.ScalarMetricValue(0, requests))
+	assert.Equal(t, first, *in.LoopMetricValue(0, requests, 0))
+	assert.Equal(t, []int{0, 1}, in.NonHistMetricLoops[0][requests])
 }
 
 // If the endpoint does not exist, fail gracefully
@@ -101,7 +114,7 @@ func TestRunCollectHTTPNoEndpoint(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 
 	assert.EqualError(t, err, fmt.Sprintf("error 404 for %s (176 bytes)", baseURL))
 }
@@ -169,7 +182,7 @@ func TestRunCollectHTTPMultipleEndpoints(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 	assert.NoError(t, err)
 	assert.True(t, fooCalled) // ensure that the /foo/ handler is called
 	assert.True(t, barCalled) // ensure that the /bar/ handler is called
@@ -245,7 +258,7 @@ func TestRunCollectHTTPSingleEndpointMultipleCalls(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 	assert.NoError(t, err)
 	assert.True(t, fooCalled) // ensure that the /foo/ handler is called
 	assert.True(t, barCalled) // ensure that the /bar/ handler is called
@@ -306,7 +319,7 @@ func TestRunCollectHTTPMultipleNoEndpoints(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 	assert.NoError(t, err)
 
 	// No metrics should be collected
@@ -342,3 +355,77 @@ func TestErrorCode(t *testing.T) {
 	})
 	assert.True(t, task.errorCode(5))
 }
+
+func TestGetFortioOptionsParams(t *testing.T) {
+	e := endpoint{
+		QPS:                float32Pointer(defaultQPS),
+		Connections:        intPointer(defaultHTTPConnections),
+		AllowInitialErrors: BoolPointer(false),
+		URL:                "http://localhost/search?lang=en",
+		Params:             map[string]string{"q": "red shoes"},
+	}
+	fo, err := getFortioOptions(e)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost/search?lang=en&q=red+shoes", fo.URL)
+}
+
+// Results stored before per-loop semantics were defined can be reused in later loops
+func TestRunCollectHTTPPreviousResult(t *testing.T) {
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	mux.HandleFunc("/"+foo, func(w http.ResponseWriter, r *http.Request) {})
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				NumRequests: int64Pointer(5),
+				URL:         fmt.Sprintf("http://localhost:%d/%s", addr.Port, foo),
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{ct},
+	}
+	err := yaml.Unmarshal([]byte(`
+numLoops: 1
+insights:
+  numVersions: 1
+  metricsInfo:
+    http/request-count:
+      description: number of requests sent
+      type: Counter
+    http/error-rate:
+      description: fraction of responses that were errors
+      type: Gauge
+    http/latency-mean:
+      description: mean of observed latency values
+      type: Gauge
+      units: msec
+  nonHistMetricValues:
+  - http/request-count: [10]
+    http/error-rate: [0.1]
+    http/latency-mean: [20]
+  histMetricValues:
+  - {}
+  summaryMetricValues:
+  - {}
+`), &exp.Result)
+	assert.NoError(t, err)
+
+	exp.Result.NumLoops++
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+	in := exp.Result.Insights
+	requests := httpMetricPrefix + "/" + builtInHTTPRequestCountID
+	assert.True(t, in.MetricsInfo[requests].PerLoop)
+	assert.Equal(t, float64(15), *in.ScalarMetricValue(0, requests))
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorRateID))
+
+	// metric meta that differs in other ways is still rejected
+	mm := in.MetricsInfo[requests]
+	mm.Type = GaugeMetricType
+	in.MetricsInfo[requests] = mm
+	assert.ErrorContains(t, ct.Run(context.Background(), exp), "old and new metric meta for "+requests+" differ")
+}
@@ -0,0 +1,144 @@
+package base
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/antonmedv/expr"
+)
+
+// conditionOptions returns the options used to compile the if condition of a task against the experiment
+//
+// In addition to the fields and methods of the experiment, such as SLOs(), conditions may use the following functions:
+//
+//	metric(name, version) is the value of the metric for the version; example, metric("http/latency-p95", 1)
+//	winner(reward) is the index of the best version for the reward metric, or -1 if there is no winner
+//	satisfies(version) is true if the version satisfies all SLOs
+//	score(version) is the canary score of the version, between 0 and 100
+//	loop() is the number of the current experiment loop, starting from 1
+//	loopMetric(name, version, loop) is the value of the counter or gauge metric for the version at the end of the loop
+//
+// For example, the following condition is true if the p95 latency of the candidate regressed by more than 10%:
+//
+//	metric("http/latency-p95", 1) > 1.1 * metric("http/latency-p95", 0)
+//
+// Data may be missing, for example, before metrics are collected, or for a version without observations.
+// In that case, metric, loopMetric and score return NaN, and every comparison with NaN other than != is false;
+// winner returns -1, and satisfies returns false. Negative versions are an error.
+func conditionOptions(exp *Experiment) []expr.Option {
+	return []expr.Option{
+		expr.Env(exp),
+		expr.AsBool(),
+		expr.Function("metric", func(params ...interface{}) (interface{}, error) {
+			return exp.metricValue(params[0].(string), params[1].(int))
+		}, new(func(string, int) float64)),
+		expr.Function("winner", func(params ...interface{}) (interface{}, error) {
+			return exp.rewardWinner(params[0].(string))
+		}, new(func(string) int)),
+		expr.Function("satisfies", func(params ...interface{}) (interface{}, error) {
+			return exp.satisfiesSLOs(params[0].(int))
+		}, new(func(int) bool)),
+		expr.Function("score", func(params ...interface{}) (interface{}, error) {
+			return exp.canaryScore(params[0].(int))
+		}, new(func(int) float64)),
+		expr.Function("loopMetric", func(params ...interface{}) (interface{}, error) {
+			return exp.loopMetricValue(params[0].(string), params[1].(int), params[2].(int))
+		}, new(func(string, int, int) float64)),
+		expr.Function("loop", func(params ...interface{}) (interface{}, error) {
+			if exp.Result == nil {
+				return 0, nil
+			}
+			return exp.Result.NumLoops, nil
+		}, new(func() int)),
+	}
+}
+
+// hasVersion returns true if the experiment has insights for the given version
+// an error is returned if the version is negative
+func (exp *Experiment) hasVersion(i int) (bool, error) {
+	if i < 0 {
+		return false, fmt.Errorf("version %v is out of range", i)
+	}
+	if exp.Result == nil || exp.Result.Insights == nil {
+		return false, nil
+	}
+	return i < exp.Result.Insights.NumVersions, nil
+}
+
+// metricValue returns the value of the scalar metric for the given version
+// NaN is returned if the metric has no value for the version
+func (exp *Experiment) metricValue(m string, i int) (float64, error) {
+	ok, err := exp.hasVersion(i)
+	if !ok {
+		return math.NaN(), err
+	}
+	v := exp.Result.Insights.ScalarMetricValue(i, m)
+	if v == nil {
+		return math.NaN(), nil
+	}
+	return *v, nil
+}
+
+// loopMetricValue returns the value of the counter or gauge metric for the given version at the end of the given loop
+// NaN is returned if the metric has no value for the version in the loop
+func (exp *Experiment) loopMetricValue(m string, i int, loop int) (float64, error) {
+	ok, err := exp.hasVersion(i)
+	if !ok {
+		return math.NaN(), err
+	}
+	v := exp.Result.Insights.LoopMetricValue(i, m, loop)
+	if v == nil {
+		return math.NaN(), nil
+	}
+	return *v, nil
+}
+
+// rewardWinner returns the index of the best version for the given reward metric
+// -1 is returned if no version is the winner, or if the reward has not been assessed
+func (exp *Experiment) rewardWinner(reward string) (int, error) {
+	if exp.Result == nil || exp.Result.Insights == nil || exp.Result.Insights.Rewards == nil {
+		return -1, nil
+	}
+	in := exp.Result.Insights
+	if in.RewardsWinners == nil {
+		return -1, nil
+	}
+	for i, m := range in.Rewards.Max {
+		if m == reward && i < len(in.RewardsWinners.Max) {
+			return in.RewardsWinners.Max[i], nil
+		}
+	}
+	for i, m := range in.Rewards.Min {
+		if m == reward && i < len(in.RewardsWinners.Min) {
+			return in.RewardsWinners.Min[i], nil
+		}
+	}
+	return -1, nil
+}
+
+// satisfiesSLOs returns true if the given version satisfies all SLOs
+// false is returned if the version has not been assessed
+func (exp *Experiment) satisfiesSLOs(i int) (bool, error) {
+	if ok, err := exp.hasVersion(i); !ok {
+		return false, err
+	}
+	for _, j := range exp.getSLOsSatisfiedBy() {
+		if j == i {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// canaryScore returns the canary score of the given version
+// NaN is returned if the version has no canary score
+func (exp *Experiment) canaryScore(i int) (float64, error) {
+	if ok, err := exp.hasVersion(i); !ok {
+		return math.NaN(), err
+	}
+	cs := exp.Result.Insights.CanaryScores
+	if cs == nil || i >= len(cs.Scores) || cs.Scores[i] == nil {
+		return math.NaN(), nil
+	}
+	return *cs.Scores[i], nil
+}
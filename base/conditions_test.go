@@ -0,0 +1,90 @@
+package base
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionFunctions(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	exp := &Experiment{}
+	exp.initResults(1)
+	exp.Result.NumLoops = 2
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	in := exp.Result.Insights
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 0, float64(100)))
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 1, float64(120)))
+	assert.NoError(t, in.setRewards(&Rewards{
+		Max: []string{"a/conversion"},
+		Min: []string{"a/latency"},
+	}))
+	assert.NoError(t, in.setSLOs(&SLOLimits{
+		Upper: []SLO{{Metric: "a/latency", Limit: 110}},
+	}))
+	assert.NoError(t, exp.initializeSLOsSatisfied())
+	in.SLOsSatisfied.Upper[0] = []bool{true, false}
+	in.RewardsWinners = &RewardsWinners{
+		Max: []int{1},
+		Min: []int{0},
+	}
+
+	for _, c := range []struct {
+		cond    string
+		want    bool
+		wantErr bool
+	}{
+		{cond: `metric("a/latency", 1) > 1.1 * metric("a/latency", 0)`, want: true},
+		{cond: `metric("a/latency", 0) == 100.0`, want: true},
+		{cond: `winner("a/conversion") == 1`, want: true},
+		{cond: `winner("a/latency") == 1`, want: false},
+		{cond: `satisfies(0) && !satisfies(1)`, want: true},
+		{cond: `loop() == 2`, want: true},
+		{cond: `loopMetric("a/latency", 1, 2) == 120.0`, want: true},
+		{cond: `loopMetric("a/latency", 1, 1) > 0`, want: false},
+		{cond: `loopMetric("a/latency", 1, 1) <= 0`, want: false},
+		{cond: `metric("a/unknown", 0) > 0`, want: false},
+		{cond: `metric("a/unknown", 0) <= 0`, want: false},
+		{cond: `metric("a/latency", 2) > 0`, want: false},
+		{cond: `score(0) >= 0`, want: false},
+		{cond: `winner("a/other") == -1`, want: true},
+		{cond: `satisfies(2)`, want: false},
+		{cond: `satisfies(-1)`, wantErr: true},
+		{cond: `metric("a/latency", -1) > 0`, wantErr: true},
+	} {
+		task := &runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello"), If: StringPointer(c.cond)}}
+		ok, err := evaluateIf(task, exp)
+		if c.wantErr {
+			assert.Error(t, err, c.cond)
+			continue
+		}
+		assert.NoError(t, err, c.cond)
+		assert.Equal(t, c.want, ok, c.cond)
+	}
+
+	// missing metrics and rewards do not fail conditions before insights are available
+	empty := &Experiment{}
+	empty.initResults(1)
+	for _, cond := range []string{
+		`metric("a/latency", 1) > 1.1 * metric("a/latency", 0)`,
+		`loopMetric("a/latency", 0, 1) < 100`,
+		`winner("a/conversion") == 1`,
+		`satisfies(0)`,
+	} {
+		task := &runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello"), If: StringPointer(cond)}}
+		ok, err := evaluateIf(task, empty)
+		assert.NoError(t, err, cond)
+		assert.False(t, ok, cond)
+	}
+
+	// conditions using these functions are valid
+	spec := ExperimentSpec{
+		&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello"), If: StringPointer(`winner("a/b") == 1 && satisfies(1)`)}},
+		&runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello"), If: StringPointer(`metric("a/b")`)}},
+	}
+	problems := spec.Validate()
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, "spec[1].if", problems[0].Path)
+}
@@ -2,6 +2,7 @@ package base
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"time"
 
@@ -43,7 +45,10 @@ type Metric struct {
 	// Description is the description of the metric
 	Description *string `json:"description,omitempty" yaml:"description,omitempty"`
 
-	// Type is the type of the metric, either gauge or counter
+	// Type is the type of the metric, either gauge, counter or histogram
+	// The jq expression of a histogram metric extracts a list of buckets,
+	// either as {"lower": 0, "upper": 10, "count": 5} or as cumulative Prometheus buckets {"le": "10", "count": "5"}
+	// The histogram queried in a loop replaces the histogram queried in earlier loops
 	Type string `json:"type" yaml:"type"`
 
 	// Units is the unit of the metric, which can be omitted for unitless metrics
@@ -99,19 +104,32 @@ const (
 type customMetricsTask struct {
 	TaskMeta
 	With customMetricsInputs `json:"with" yaml:"with"`
+	// values are the metric values collected in the latest run, keyed by metric name
+	// values[m][i] is the value of metric m for version i, or nil if there is no value
+	values map[string]interface{}
 }
 
-// initializeDefaults sets default values for the custom metrics task
-func (t *customMetricsTask) initializeDefaults() {
+// InitializeDefaults sets default values for the custom metrics task
+func (t *customMetricsTask) InitializeDefaults() {
 	// initialize versionValues if absent
 	if len(t.With.VersionValues) == 0 {
 		t.With.VersionValues = []map[string]interface{}{t.With.Values}
 	}
 }
 
-// validate task inputs
-func (t *customMetricsTask) validateInputs() error {
-	return nil
+// ValidateInputs validates task inputs
+func (t *customMetricsTask) ValidateInputs() error {
+	errs := inputErrors{}
+	// without templates, the task has nothing to do
+	if len(t.With.Templates) == 0 {
+		log.Logger.Warn("no provider templates; custom metrics task will not collect any metrics")
+	}
+	for provider, url := range t.With.Templates {
+		if len(url) == 0 {
+			errs.add("with.templates."+provider, "template URL is required")
+		}
+	}
+	return errs.errOrNil()
 }
 
 // getElapsedTimeSeconds using values and experiment
@@ -141,14 +159,14 @@ func getElapsedTimeSeconds(values map[string]interface{}, exp *Experiment) (int6
 //
 // bool return value represents whether the pipeline was able to run to
 // completion (prevents double error statement)
-func queryDatabaseAndGetValue(template ProviderSpec, metric Metric) (interface{}, bool) {
+func queryDatabaseAndGetValue(ctx context.Context, template ProviderSpec, metric Metric) (interface{}, bool) {
 	var requestBody io.Reader
 	if metric.Body != nil {
 		requestBody = strings.NewReader(*metric.Body)
 	}
 
 	// create a new HTTP request
-	req, err := http.NewRequest(template.Method, template.URL, requestBody)
+	req, err := http.NewRequestWithContext(ctx, template.Method, template.URL, requestBody)
 	if err != nil {
 		log.Logger.Error("could not create new request for metric ", metric.Name, ": ", err)
 		return nil, false
@@ -215,77 +233,110 @@ func queryDatabaseAndGetValue(template ProviderSpec, metric Metric) (interface{}
 	return value, true
 }
 
-// run executes this task
-func (t *customMetricsTask) run(exp *Experiment) error {
+// getProviderSpec renders the provider template with the values for the given version
+func (t *customMetricsTask) getProviderSpec(template *template.Template, i int, exp *Experiment) (*ProviderSpec, error) {
+	// merge values
+	vals, err := mustMergeOverwrite(t.With.Values, t.With.VersionValues[i])
+	if err != nil {
+		return nil, err
+	}
+	values := vals.(map[string]interface{})
+	if len(values) == 0 {
+		values = make(map[string]interface{})
+	}
+	// add elapsedTimeSeconds
+	elapsedTimeSeconds, err := getElapsedTimeSeconds(values, exp)
+	if err != nil {
+		return nil, err
+	}
+	values[elapsedTimeSecondsStr] = elapsedTimeSeconds
+
+	// get the metrics spec
+	var buf bytes.Buffer
+	err = template.Execute(&buf, values)
+	if err != nil {
+		log.Logger.Error("cannot execute metrics spec with values", err)
+		log.Logger.Error("metrics spec: ", buf.String())
+		log.Logger.Error("values: ", values)
+		return nil, err
+	}
+
+	bytes, _ := io.ReadAll(&buf)
+	var provider ProviderSpec
+	err = yaml.Unmarshal(bytes, &provider)
+	if err != nil {
+		log.Logger.Error("cannot unmarshal provider spec", err)
+		log.Logger.Error("provider spec: ", string(bytes))
+		return nil, err
+	}
+	log.Logger.Debug("--------------------------------")
+	log.Logger.Debug(string(bytes))
+	return &provider, nil
+}
+
+// plan resolves the metric queries of the task for each version without sending them
+// Provider templates are fetched and rendered, and the resulting provider specs are returned keyed by provider
+func (t *customMetricsTask) plan(ctx context.Context, exp *Experiment) (interface{}, error) {
+	if err := exp.Result.initInsightsWithNumVersions(len(t.With.VersionValues)); err != nil {
+		return nil, err
+	}
+	providers := map[string][]ProviderSpec{}
+	for providerName, url := range t.With.Templates {
+		template, err := getTextTemplateFromURL(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		for i := range t.With.VersionValues {
+			provider, err := t.getProviderSpec(template, i, exp)
+			if err != nil {
+				return nil, err
+			}
+			providers[providerName] = append(providers[providerName], *provider)
+		}
+	}
+	return providers, nil
+}
+
+// Run executes this task
+func (t *customMetricsTask) Run(ctx context.Context, exp *Experiment) error {
 	// validate inputs
 	var err error
 
-	err = t.validateInputs()
+	err = t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
 	// initialize defaults
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	err = exp.Result.initInsightsWithNumVersions(len(t.With.VersionValues))
 	if err != nil {
 		return err
 	}
+	t.values = map[string]interface{}{}
 
 	// collect metrics from all providers and for all versions
 	for providerName, url := range t.With.Templates {
 		// finalize metrics spec
-		template, err := getTextTemplateFromURL(url)
+		template, err := getTextTemplateFromURL(ctx, url)
 		if err != nil {
 			return err
 		}
 
-		for i, versionValues := range t.With.VersionValues {
-			// merge values
-			vals, err := mustMergeOverwrite(t.With.Values, versionValues)
-			if err != nil {
-				return err
-			}
-			values := vals.(map[string]interface{})
-			if len(values) == 0 {
-				values = make(map[string]interface{})
-			}
-			// add elapsedTimeSeconds
-			elapsedTimeSeconds, err := getElapsedTimeSeconds(values, exp)
-			if err != nil {
-				return err
-			}
-			values[elapsedTimeSecondsStr] = elapsedTimeSeconds
-
-			// get the metrics spec
-			var buf bytes.Buffer
-			err = template.Execute(&buf, values)
+		for i := range t.With.VersionValues {
+			provider, err := t.getProviderSpec(template, i, exp)
 			if err != nil {
-				log.Logger.Error("cannot execute metrics spec with values", err)
-				log.Logger.Error("metrics spec: ", buf.String())
-				log.Logger.Error("values: ", values)
-				return err
-			}
-
-			bytes, _ := io.ReadAll(&buf)
-			var provider ProviderSpec
-			err = yaml.Unmarshal(bytes, &provider)
-			if err != nil {
-				log.Logger.Error("cannot unmarshal provider spec", err)
-				log.Logger.Error("provider spec: ", string(bytes))
 				return err
 			}
 			log.Logger.Debugf("provider spec %v for version %v\n", providerName, i)
-			log.Logger.Debug("--------------------------------")
-			log.Logger.Debug(string(bytes))
 
 			// get each metric
 			for _, metric := range provider.Metrics {
 				log.Logger.Debug("query for metric ", metric.Name)
 
 				// perform database query and extract metric value
-				val, ok := queryDatabaseAndGetValue(provider, metric)
+				val, ok := queryDatabaseAndGetValue(ctx, *provider, metric)
 
 				// check if there were any issues querying database and extracting value
 				if !ok {
@@ -305,6 +356,8 @@ func (t *customMetricsTask) run(exp *Experiment) error {
 					metricType = GaugeMetricType
 				} else if metric.Type == "counter" {
 					metricType = CounterMetricType
+				} else if metric.Type == "histogram" {
+					metricType = HistogramMetricType
 				}
 
 				// finalize metric data
@@ -314,6 +367,22 @@ func (t *customMetricsTask) run(exp *Experiment) error {
 					Units:       metric.Units,
 				}
 
+				// histogram metrics are lists of buckets
+				if metricType == HistogramMetricType {
+					buckets, err := histBucketsFromValue(val)
+					if err != nil {
+						log.Logger.Error("could not extract histogram for metric ", metric.Name, ": ", err)
+						continue
+					}
+					t.recordValue(providerName+"/"+metric.Name, i, val)
+					// queries return snapshots, so the histogram replaces the one from earlier loops
+					err = exp.Result.Insights.replaceMetricHist(providerName+"/"+metric.Name, mm, i, buckets)
+					if err != nil {
+						log.Logger.Error("could not add update metric", err)
+					}
+					continue
+				}
+
 				// convert value to float
 				valueString := fmt.Sprint(val)
 				floatValue, err := strconv.ParseFloat(valueString, 64)
@@ -327,6 +396,7 @@ func (t *customMetricsTask) run(exp *Experiment) error {
 					continue
 				}
 
+				t.recordValue(providerName+"/"+metric.Name, i, floatValue)
 				err = exp.Result.Insights.updateMetric(providerName+"/"+metric.Name, mm, i, floatValue)
 
 				if err != nil {
@@ -339,3 +409,17 @@ func (t *customMetricsTask) run(exp *Experiment) error {
 
 	return nil
 }
+
+// recordValue records the value of a metric for a version, so that it can be published as output
+func (t *customMetricsTask) recordValue(m string, i int, val interface{}) {
+	if _, ok := t.values[m]; !ok {
+		t.values[m] = make([]interface{}, len(t.With.VersionValues))
+	}
+	t.values[m].([]interface{})[i] = val
+}
+
+// getOutput returns the metric values collected by the task, keyed by metric name
+// Example: the jq expression '.["istio/request-count"][0]' extracts the request count of the first version
+func (t *customMetricsTask) getOutput() interface{} {
+	return t.values
+}
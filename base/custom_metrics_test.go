@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -274,7 +275,7 @@ func TestIstioProm(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -323,7 +324,7 @@ func TestNaN(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// task run should not fail
 	assert.NoError(t, err)
@@ -408,7 +409,7 @@ func TestCEOneVersion(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -498,7 +499,7 @@ func TestCEVersionValues(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -542,7 +543,7 @@ func TestCEUnauthorized(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -617,7 +618,7 @@ func TestCESomeValues(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -699,7 +700,7 @@ func TestCEMultipleVersions(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(2)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -783,7 +784,7 @@ func TestCEMultipleVersionsAndMetrics(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(2)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -850,10 +851,56 @@ func TestRequestBody(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = ct.run(exp)
+	err = ct.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
 
 	assert.Equal(t, exp.Result.Insights.NonHistMetricValues[0][testRequestBody+"/request-count"][0], float64(43))
 }
+
+func TestHistogramMetric(t *testing.T) {
+	dat, err := os.ReadFile(CompletePath("../testdata/custommetrics", "histogram.tpl"))
+	assert.NoError(t, err)
+
+	_ = os.Chdir(t.TempDir())
+	ct := getCustomMetricsTask(t, "hist", "http://url")
+	httpmock.RegisterResponder("GET", "http://url",
+		httpmock.NewStringResponder(200, string(dat)))
+	// cumulative buckets, as returned by Prometheus
+	httpmock.RegisterResponder("GET", "http://url/query",
+		httpmock.NewStringResponder(200, `{"data": {"result": [
+			{"metric": {"le": "0.1"}, "value": [1645602108.839, "50"]},
+			{"metric": {"le": "+Inf"}, "value": [1645602108.839, "100"]},
+			{"metric": {"le": "0.2"}, "value": [1645602108.839, "90"]},
+			{"metric": {"le": "0.4"}, "value": [1645602108.839, "100"]}
+		]}}`))
+
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(1)
+
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	in := exp.Result.Insights
+	assert.Equal(t, HistogramMetricType, in.MetricsInfo["hist/latency"].Type)
+	assert.Equal(t, []HistBucket{
+		{Lower: 0, Upper: 0.1, Count: 50},
+		{Lower: 0.1, Upper: 0.2, Count: 40},
+		{Lower: 0.2, Upper: 0.4, Count: 10},
+	}, in.HistMetricValues[0]["hist/latency"])
+	assert.InDelta(t, 0.1, *in.ScalarMetricValue(0, "hist/latency/p50"), 1e-9)
+	assert.InDelta(t, 0.4, *in.ScalarMetricValue(0, "hist/latency/max"), 1e-9)
+
+	// the snapshot replaces the histogram of earlier loops, so counts do not grow with the number of loops
+	for loop := 2; loop <= 3; loop++ {
+		exp.Result.NumLoops = loop
+		assert.NoError(t, ct.Run(context.Background(), exp))
+		assert.InDelta(t, 100, *in.ScalarMetricValue(0, "hist/latency/count"), 1e-9)
+		assert.InDelta(t, 0.1, *in.ScalarMetricValue(0, "hist/latency/p50"), 1e-9)
+	}
+}
@@ -0,0 +1,165 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// DerivedMetricsTaskName is the name of the task this file implements
+	DerivedMetricsTaskName = "derivedmetrics"
+
+	// derivedMetricPrefix is the backend under which derived metrics are registered
+	derivedMetricPrefix = "derived"
+)
+
+// DerivedMetric defines a metric that is computed from other metrics of the same version
+type DerivedMetric struct {
+	// Name is the name of the metric; the metric is registered as derived/name
+	Name string `json:"name" yaml:"name"`
+
+	// Description is the description of the metric
+	Description *string `json:"description,omitempty" yaml:"description,omitempty"`
+
+	// Type is the type of the metric, either gauge or counter
+	// Default value is gauge
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Units is the unit of the metric, which can be omitted for unitless metrics
+	Units *string `json:"units,omitempty" yaml:"units,omitempty"`
+
+	// Expression computes the value of the metric for a version
+	// metric(name) is the value of another metric for the same version
+	// Example: metric("abn/revenue/sum") / metric("abn/revenue/count")
+	Expression string `json:"expression" yaml:"expression"`
+}
+
+// derivedMetricsInputs is the input to the derivedmetrics task
+type derivedMetricsInputs struct {
+	// Metrics are computed in order, so that a metric can refer to the ones defined before it
+	Metrics []DerivedMetric `json:"metrics" yaml:"metrics"`
+}
+
+// derivedMetricsTask computes metrics from the metrics collected by earlier tasks
+type derivedMetricsTask struct {
+	// TaskMeta has fields common to all tasks
+	TaskMeta
+	// With contains the inputs to this task
+	With derivedMetricsInputs `json:"with" yaml:"with"`
+	// programs are the compiled expressions of the metrics, set when inputs are validated
+	programs []*vm.Program
+}
+
+// derivedMetricEnv is the environment in which the expression of a derived metric is evaluated for a version
+type derivedMetricEnv struct {
+	// Metric returns the value of another metric for the version
+	Metric func(string) (float64, error) `expr:"metric"`
+}
+
+// compileDerivedMetric compiles the expression of a derived metric, so that it can be evaluated for each version
+func compileDerivedMetric(m DerivedMetric) (*vm.Program, error) {
+	return expr.Compile(m.Expression, expr.Env(derivedMetricEnv{}), expr.AsFloat64())
+}
+
+// InitializeDefaults sets default values for task inputs
+func (t *derivedMetricsTask) InitializeDefaults() {
+	for i := range t.With.Metrics {
+		if len(t.With.Metrics[i].Type) == 0 {
+			t.With.Metrics[i].Type = "gauge"
+		}
+	}
+}
+
+// ValidateInputs for this task
+func (t *derivedMetricsTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if len(t.With.Metrics) == 0 {
+		errs.add("with.metrics", "at least one metric is required")
+	}
+	names := map[string]bool{}
+	t.programs = make([]*vm.Program, len(t.With.Metrics))
+	for i, m := range t.With.Metrics {
+		path := fmt.Sprintf("with.metrics[%v]", i)
+		if len(m.Name) == 0 || strings.Contains(m.Name, "/") {
+			errs.add(joinPath(path, "name"), "name is required and cannot contain /")
+		} else if names[m.Name] {
+			errs.add(joinPath(path, "name"), "metric %v is defined more than once", m.Name)
+		}
+		names[m.Name] = true
+		if len(m.Type) > 0 && m.Type != "gauge" && m.Type != "counter" {
+			errs.add(joinPath(path, "type"), "type must be gauge or counter")
+		}
+		var err error
+		if t.programs[i], err = compileDerivedMetric(m); err != nil {
+			errs.add(joinPath(path, "expression"), "invalid expression: %v", err)
+		}
+	}
+	return errs.errOrNil()
+}
+
+// Run executes the derivedmetrics task
+// Versions for which an expression cannot be evaluated, for example because a metric is not available, are skipped
+func (t *derivedMetricsTask) Run(ctx context.Context, exp *Experiment) error {
+	err := t.ValidateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.InitializeDefaults()
+
+	if exp.Result.Insights == nil {
+		log.Logger.Error("uninitialized insights within experiment")
+		return errors.New("uninitialized insights within experiment")
+	}
+	in := exp.Result.Insights
+	// derived metrics are observed in the current loop
+	if err = exp.Result.initInsightsWithNumVersions(in.NumVersions); err != nil {
+		return err
+	}
+
+	for j, m := range t.With.Metrics {
+		name := derivedMetricPrefix + "/" + m.Name
+		mm := MetricMeta{
+			Description: m.Expression,
+			Units:       m.Units,
+			Type:        GaugeMetricType,
+			// derived values are computed from the values of metrics over all loops, so counters are snapshots
+			PerLoop: false,
+		}
+		if m.Description != nil {
+			mm.Description = *m.Description
+		}
+		if m.Type == "counter" {
+			mm.Type = CounterMetricType
+		}
+
+		for i := 0; i < in.NumVersions; i++ {
+			env := derivedMetricEnv{
+				Metric: func(metric string) (float64, error) {
+					return exp.metricValue(metric, i)
+				},
+			}
+			out, err := expr.Run(t.programs[j], env)
+			if err != nil {
+				log.Logger.Warnf("could not derive metric %v for version %v: %v", name, i, err)
+				continue
+			}
+			val := out.(float64)
+			if math.IsNaN(val) || math.IsInf(val, 0) {
+				log.Logger.Warnf("derived metric %v for version %v is not a number; ignored", name, i)
+				continue
+			}
+			if err = in.updateMetric(name, mm, i, val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDerivedMetrics(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	exp := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- task: derivedmetrics
+  with:
+    metrics:
+    - name: error-ratio
+      description: fraction of requests with errors
+      expression: metric("custom/errors") / metric("custom/requests")
+    - name: error-percent
+      units: percent
+      expression: 100 * metric("derived/error-ratio")
+- task: assess
+  with:
+    SLOs:
+      upper:
+      - metric: derived/error-percent
+        limit: 5
+`), exp)
+	assert.NoError(t, err)
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(3)
+	in := exp.Result.Insights
+	for i, v := range [][]float64{{1000, 20}, {1000, 80}, {0, 0}} {
+		assert.NoError(t, in.updateMetric("custom/requests", MetricMeta{Type: CounterMetricType}, i, v[0]))
+		assert.NoError(t, in.updateMetric("custom/errors", MetricMeta{Type: CounterMetricType}, i, v[1]))
+	}
+
+	for _, task := range exp.Spec {
+		assert.NoError(t, task.Run(context.Background(), exp))
+	}
+
+	assert.Equal(t, MetricMeta{
+		Description: "fraction of requests with errors",
+		Type:        GaugeMetricType,
+	}, in.MetricsInfo["derived/error-ratio"])
+	assert.Equal(t, "percent", *in.MetricsInfo["derived/error-percent"].Units)
+	assert.InDelta(t, 0.02, *in.ScalarMetricValue(0, "derived/error-ratio"), 1e-9)
+	assert.InDelta(t, 8, *in.ScalarMetricValue(1, "derived/error-percent"), 1e-9)
+	// 0/0 is not a number
+	assert.Nil(t, in.ScalarMetricValue(2, "derived/error-ratio"))
+	assert.Equal(t, []bool{true, false, false}, in.SLOsSatisfied.Upper[0])
+}
+
+func TestDerivedCounterOverLoops(t *testing.T) {
+	task := &derivedMetricsTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(DerivedMetricsTaskName),
+		},
+		With: derivedMetricsInputs{
+			Metrics: []DerivedMetric{{Name: "failures", Type: "counter", Expression: `metric("custom/errors") + metric("custom/timeouts")`}},
+		},
+	}
+	exp := &Experiment{Spec: []Task{task}}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(1)
+	in := exp.Result.Insights
+
+	// per-loop counts of errors and timeouts in two loops
+	perLoopCounter := MetricMeta{Type: CounterMetricType, PerLoop: true}
+	for loop, v := range [][]float64{{10, 5}, {20, 1}} {
+		exp.Result.NumLoops = loop + 1
+		assert.NoError(t, in.updateMetric("custom/errors", perLoopCounter, 0, v[0]))
+		assert.NoError(t, in.updateMetric("custom/timeouts", perLoopCounter, 0, v[1]))
+		assert.NoError(t, task.Run(context.Background(), exp))
+	}
+
+	// the derived counter is a snapshot of the counts over all loops, which is not summed again
+	assert.Equal(t, MetricMeta{
+		Description: `metric("custom/errors") + metric("custom/timeouts")`,
+		Type:        CounterMetricType,
+		PerLoop:     false,
+	}, in.MetricsInfo["derived/failures"])
+	assert.Equal(t, []float64{15, 36}, in.NonHistMetricValues[0]["derived/failures"])
+	assert.Equal(t, float64(36), *in.ScalarMetricValue(0, "derived/failures"))
+	assert.Equal(t, float64(15), *in.LoopMetricValue(0, "derived/failures", 1))
+}
+
+func TestDerivedMetricsInputs(t *testing.T) {
+	task := &derivedMetricsTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(DerivedMetricsTaskName),
+		},
+	}
+	assert.Error(t, task.ValidateInputs())
+
+	task.With.Metrics = []DerivedMetric{
+		{Name: "a/b", Expression: `metric("x/y")`},
+		{Name: "ratio", Type: "histogram", Expression: `metric("x/y") /`},
+		{Name: "ratio", Expression: `metric("x/y", 1)`},
+	}
+	err := task.ValidateInputs()
+	var ie inputErrors
+	assert.ErrorAs(t, err, &ie)
+	assert.Equal(t, 5, len(ie))
+
+	// insights are required
+	task.With.Metrics = []DerivedMetric{{Name: "ratio", Expression: `metric("x/y") / 2`}}
+	exp := &Experiment{Spec: []Task{task}}
+	exp.initResults(1)
+	assert.Error(t, task.Run(context.Background(), exp))
+}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -19,15 +20,17 @@ import (
 
 // Task is the building block of an experiment spec
 // An experiment spec is a sequence of tasks
+// Tasks other than the built-in ones can be made available using RegisterTask
 type Task interface {
-	// validateInputs for this task
-	validateInputs() error
+	// ValidateInputs for this task
+	ValidateInputs() error
 
-	// initializeDefaults of the input values to this task
-	initializeDefaults()
+	// InitializeDefaults of the input values to this task
+	InitializeDefaults()
 
-	// run this task
-	run(exp *Experiment) error
+	// Run this task
+	// Run is expected to return promptly once ctx is done
+	Run(ctx context.Context, exp *Experiment) error
 }
 
 // ExperimentSpec specifies the set of tasks in this experiment
@@ -63,13 +66,67 @@ type ExperimentResult struct {
 	// Failure is true if any of its tasks failed
 	Failure bool `json:"failure" yaml:"failure"`
 
+	// Interrupted is true if the experiment run was cancelled before it completed
+	Interrupted bool `json:"interrupted,omitempty" yaml:"interrupted,omitempty"`
+
+	// TaskResults records the execution of each task in the latest experiment loop
+	// TaskResults[i] corresponds to the i-th task in the experiment spec; tasks that have not yet been executed are absent
+	TaskResults []TaskResult `json:"taskResults,omitempty" yaml:"taskResults,omitempty"`
+
+	// Outputs are the values published by tasks for use by later tasks
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+
 	// Insights produced in this experiment
 	Insights *Insights `json:"insights,omitempty" yaml:"insights,omitempty"`
 
+	// Decision is the outcome of sequential testing of reward metrics; promote, rollback, or continue
+	// It is set by the assess task when sequential testing is configured
+	Decision Decision `json:"decision,omitempty" yaml:"decision,omitempty"`
+
 	// Iter8Version is the version of Iter8 CLI that created this result object
 	Iter8Version string `json:"iter8Version" yaml:"iter8Version"`
 }
 
+// TaskStatus is the outcome of executing a task
+type TaskStatus string
+
+const (
+	// TaskSucceeded indicates that the task ran successfully
+	TaskSucceeded TaskStatus = "succeeded"
+	// TaskFailed indicates that the task ran and failed
+	TaskFailed TaskStatus = "failed"
+	// TaskSkipped indicates that the task did not run because its if condition was false
+	TaskSkipped TaskStatus = "skipped"
+)
+
+// TaskResult records the execution of a task
+type TaskResult struct {
+	// Name of the task
+	Name string `json:"name" yaml:"name"`
+
+	// Status of the task
+	Status TaskStatus `json:"status" yaml:"status"`
+
+	// StartTime is the time when the task started
+	StartTime time.Time `json:"startTime" yaml:"startTime"`
+
+	// EndTime is the time when the task ended
+	EndTime time.Time `json:"endTime" yaml:"endTime"`
+
+	// Duration of the task, including retries. Specified in the Go duration string format (example, 1.5s).
+	Duration string `json:"duration" yaml:"duration"`
+
+	// Attempts is the number of times the task was attempted; skipped tasks and tasks whose condition could not be evaluated have no attempts
+	Attempts int `json:"attempts" yaml:"attempts"`
+
+	// Error is the error message of the last failed attempt, if the task failed
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	// Parallel records the execution of each task in a parallel block during the last attempt of the block
+	// Parallel[j] corresponds to the j-th task in the block; it is named after the position and name of the task, for example, parallel[0].http
+	Parallel []TaskResult `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+}
+
 // Insights records the number of versions in this experiment,
 // metric values and SLO indicators for each version,
 // metrics metadata for all metrics, and
@@ -91,6 +148,11 @@ type Insights struct {
 	// this struct is meant exclusively for metrics of type other than histogram
 	NonHistMetricValues []map[string][]float64 `json:"nonHistMetricValues,omitempty" yaml:"nonHistMetricValues,omitempty"`
 
+	// NonHistMetricLoops:
+	// value [i]["foo/bar"][k] is the experiment loop in which NonHistMetricValues[i]["foo/bar"][k] was observed
+	// loops are recorded only for counter and gauge metrics; loop 0 means the loop is not known
+	NonHistMetricLoops []map[string][]int `json:"nonHistMetricLoops,omitempty" yaml:"nonHistMetricLoops,omitempty"`
+
 	// HistMetricValues:
 	// the outer slice must be the same length as the number of app versions
 	// the map key must match name of a histogram metric in MetricsInfo
@@ -108,11 +170,27 @@ type Insights struct {
 	// SLOsSatisfied indicator matrices that show if upper and lower SLO limits are satisfied
 	SLOsSatisfied *SLOResults `json:"SLOsSatisfied,omitempty" yaml:"SLOsSatisfied,omitempty"`
 
+	// EffectiveSLOLimits are the limits that versions were evaluated against
+	// they differ from the SLO limits for SLOs that are relative to the baseline version
+	EffectiveSLOLimits *EffectiveLimits `json:"effectiveSLOLimits,omitempty" yaml:"effectiveSLOLimits,omitempty"`
+
 	// Rewards involed in this experiment
 	Rewards *Rewards `json:"rewards,omitempty" yaml:"rewards,omitempty"`
 
 	// RewardsWinners indicate the winners
 	RewardsWinners *RewardsWinners `json:"rewardsWinners,omitempty" yaml:"rewardsWinners,omitempty"`
+
+	// SequentialEvidence is the evidence accumulated across loops by sequential tests of reward metrics
+	SequentialEvidence *SequentialEvidence `json:"sequentialEvidence,omitempty" yaml:"sequentialEvidence,omitempty"`
+
+	// CanaryScores are the scores of versions computed from weighted comparisons of metrics with a baseline
+	CanaryScores *CanaryScores `json:"canaryScores,omitempty" yaml:"canaryScores,omitempty"`
+
+	// Regressions are the results of comparing versions with earlier runs of the experiment
+	Regressions *RegressionResults `json:"regressions,omitempty" yaml:"regressions,omitempty"`
+
+	// loop is the experiment loop in which metric values are currently observed
+	loop int
 }
 
 // MetricMeta describes a metric
@@ -123,6 +201,12 @@ type MetricMeta struct {
 	Units *string `json:"units,omitempty" yaml:"units,omitempty"`
 	// Type of the metric. Example: counter
 	Type MetricType `json:"type" yaml:"type"`
+	// PerLoop is true if each value of the metric is observed within a single loop of the experiment,
+	// rather than since the start of the experiment
+	// The value of a per-loop counter is the sum of its values in all loops;
+	// the value of a per-loop gauge is its value in the latest loop, except for per-loop rates of events,
+	// such as http/error-rate, whose value is computed from the per-loop counters of the events and requests in all loops
+	PerLoop bool `json:"perLoop,omitempty" yaml:"perLoop,omitempty"`
 }
 
 // VersionInfo is basic information about a version
@@ -140,6 +224,9 @@ type Rewards struct {
 	Max []string `json:"max,omitempty" yaml:"max,omitempty"`
 	// Min is list of reward metrics where the version with the minimum value wins
 	Min []string `json:"min,omitempty" yaml:"min,omitempty"`
+	// Confidence is the confidence level required to declare a winner for reward metrics that support significance tests
+	// Default value is 0.95
+	Confidence *float64 `json:"confidence,omitempty" yaml:"confidence,omitempty"`
 }
 
 // RewardsWinners are indices of the best versions for each reward metric
@@ -150,6 +237,35 @@ type RewardsWinners struct {
 	// Min rewards
 	// Min[i] specifies the index of the winner of reward metric Rewards.Min[i]
 	Min []int `json:"min,omitempty" yaml:"min,omitempty"`
+	// MaxStats[i] are the statistics used to decide the winner of reward metric Rewards.Max[i]
+	// MaxStats[i] is nil if the reward metric does not support significance tests
+	MaxStats []*RewardStats `json:"maxStats,omitempty" yaml:"maxStats,omitempty"`
+	// MinStats[i] are the statistics used to decide the winner of reward metric Rewards.Min[i]
+	// MinStats[i] is nil if the reward metric does not support significance tests
+	MinStats []*RewardStats `json:"minStats,omitempty" yaml:"minStats,omitempty"`
+}
+
+// RewardStats are the statistics used to decide the winner of a reward metric
+// A winner is declared only if it is significantly better than every other version
+type RewardStats struct {
+	// Test is the significance test used to compare versions
+	Test SignificanceTest `json:"test" yaml:"test"`
+	// Confidence is the confidence level of the intervals and the test
+	Confidence float64 `json:"confidence" yaml:"confidence"`
+	// Intervals[j] is the confidence interval of the metric for version j
+	// Intervals[j] is nil if version j has insufficient data
+	Intervals []*ConfidenceInterval `json:"intervals" yaml:"intervals"`
+	// PValues[j] is the p-value of the test between the best version and version j
+	// PValues[j] is nil for the best version and for versions with insufficient data
+	PValues []*float64 `json:"pValues" yaml:"pValues"`
+}
+
+// ConfidenceInterval is an interval estimate of the value of a metric
+type ConfidenceInterval struct {
+	// Lower endpoint of the interval
+	Lower float64 `json:"lower" yaml:"lower"`
+	// Upper endpoint of the interval
+	Upper float64 `json:"upper" yaml:"upper"`
 }
 
 // SLO is a service level objective
@@ -158,11 +274,37 @@ type SLO struct {
 	Metric string `json:"metric" yaml:"metric"`
 
 	// Limit is the acceptable limit for this metric
+	// Limit is ignored if the SLO is relative to the baseline version
 	Limit float64 `json:"limit" yaml:"limit"`
+
+	// Ratio makes the SLO relative to the baseline version
+	// The effective limit is Ratio times the value of the metric for the baseline version
+	// Example: a ratio of 1.1 for an upper SLO means no more than 10% worse than the baseline
+	Ratio *float64 `json:"ratio,omitempty" yaml:"ratio,omitempty"`
+
+	// Delta makes the SLO relative to the baseline version
+	// The effective limit is the value of the metric for the baseline version plus Delta
+	// Example: a delta of 0.5 for an upper SLO on error rate means no more than 0.5 points above the baseline
+	Delta *float64 `json:"delta,omitempty" yaml:"delta,omitempty"`
+
+	// Overrides map track names, or version names, to limits that replace the limit of the SLO for those versions
+	// Overrides are absolute limits, even if the SLO is relative to the baseline version
+	// A track name takes precedence over a version name
+	// Example: {"shadow": 500} allows versions in the shadow track a looser latency limit
+	Overrides map[string]float64 `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+}
+
+// relative returns true if the SLO limit is relative to the baseline version
+func (slo SLO) relative() bool {
+	return slo.Ratio != nil || slo.Delta != nil
 }
 
 // SLOLimits specify upper or lower limits for metrics
 type SLOLimits struct {
+	// Baseline is the index of the version that relative SLOs are computed against
+	// Default value is 0
+	Baseline int `json:"baseline,omitempty" yaml:"baseline,omitempty"`
+
 	// Upper limits for metrics
 	Upper []SLO `json:"upper,omitempty" yaml:"upper,omitempty"`
 
@@ -170,6 +312,25 @@ type SLOLimits struct {
 	Lower []SLO `json:"lower,omitempty" yaml:"lower,omitempty"`
 }
 
+// EffectiveLimits specify the limits computed for SLOs
+type EffectiveLimits struct {
+	// Upper[i] is the effective limit of upper SLO i
+	// it is nil if the limit is relative and the metric has no value for the baseline version
+	Upper []*float64 `json:"upper,omitempty" yaml:"upper,omitempty"`
+
+	// Lower[i] is the effective limit of lower SLO i
+	// it is nil if the limit is relative and the metric has no value for the baseline version
+	Lower []*float64 `json:"lower,omitempty" yaml:"lower,omitempty"`
+
+	// VersionUpper[i][j] is the effective limit of upper SLO i for version j
+	// it differs from Upper[i] if the SLO overrides the limit for version j
+	VersionUpper [][]*float64 `json:"versionUpper,omitempty" yaml:"versionUpper,omitempty"`
+
+	// VersionLower[i][j] is the effective limit of lower SLO i for version j
+	// it differs from Lower[i] if the SLO overrides the limit for version j
+	VersionLower [][]*float64 `json:"versionLower,omitempty" yaml:"versionLower,omitempty"`
+}
+
 // SLOResults specify the results of SLO evaluations
 type SLOResults struct {
 	// Upper limits for metrics
@@ -179,6 +340,23 @@ type SLOResults struct {
 	// Lower limits for metrics
 	// Lower[i][j] specifies if lower SLO i is satisfied by version j
 	Lower [][]bool `json:"lower,omitempty" yaml:"lower,omitempty"`
+
+	// InsufficientUpper[i][j] specifies if version j has insufficient data to evaluate upper SLO i
+	// Upper[i][j] is false if the data is insufficient
+	InsufficientUpper [][]bool `json:"insufficientUpper,omitempty" yaml:"insufficientUpper,omitempty"`
+
+	// InsufficientLower[i][j] specifies if version j has insufficient data to evaluate lower SLO i
+	// Lower[i][j] is false if the data is insufficient
+	InsufficientLower [][]bool `json:"insufficientLower,omitempty" yaml:"insufficientLower,omitempty"`
+}
+
+// InsufficientData returns true if version j has insufficient data to evaluate the upper or lower SLO i
+func (r *SLOResults) InsufficientData(upper bool, i int, j int) bool {
+	insufficient := r.InsufficientLower
+	if upper {
+		insufficient = r.InsufficientUpper
+	}
+	return i < len(insufficient) && j < len(insufficient[i]) && insufficient[i][j]
 }
 
 // TaskMeta provides common fields used across all tasks
@@ -190,8 +368,20 @@ type TaskMeta struct {
 	Run *string `json:"run,omitempty" yaml:"run,omitempty"`
 	// If is the condition used to determine if this task needs to run
 	// If the condition is not satisfied, then it is skipped in an experiment
+	// Conditions may also use the metric, winner, satisfies, and loop functions
 	// Example: SLOs()
+	// Example: metric("http/latency-p95", 1) > 1.1 * metric("http/latency-p95", 0)
 	If *string `json:"if,omitempty" yaml:"if,omitempty"`
+	// Timeout is the maximum duration of a single attempt of this task. Specified in the Go duration string format (example, 30s). By default, there is no timeout.
+	Timeout *string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Retries is the number of times this task is retried after a failed attempt. Default value is 0.
+	Retries *int `json:"retries,omitempty" yaml:"retries,omitempty"`
+	// Backoff is the duration to wait before the first retry; it doubles after every subsequent failed attempt. Specified in the Go duration string format (example, 5s). Default value is 1s.
+	Backoff *string `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// Outputs are values published by this task for use by later tasks.
+	// Keys are output names, and values are jq expressions that extract the output from the result of this task.
+	// Later tasks reference outputs in their inputs using Go template syntax (example, {{ .Outputs.token }}).
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
 }
 
 // taskMetaWith enables unmarshaling of tasks
@@ -200,6 +390,8 @@ type taskMetaWith struct {
 	TaskMeta
 	// With is the raw representation of task inputs
 	With map[string]interface{} `json:"with,omitempty" yaml:"with,omitempty"`
+	// Parallel is the raw representation of the tasks in a parallel block
+	Parallel json.RawMessage `json:"parallel,omitempty" yaml:"parallel,omitempty"`
 }
 
 // UnmarshalJSON will unmarshal an experiment spec from bytes
@@ -213,8 +405,8 @@ func (s *ExperimentSpec) UnmarshalJSON(data []byte) error {
 	log.Logger.Tracef("unmarshaled %v tasks into task meta", len(v))
 
 	for _, t := range v {
-		if (t.Task == nil || len(*t.Task) == 0) && (t.Run == nil) {
-			err := fmt.Errorf("invalid task found without a task name or a run command")
+		if (t.Task == nil || len(*t.Task) == 0) && (t.Run == nil) && (t.Parallel == nil) {
+			err := fmt.Errorf("invalid task found without a task name, a run command, or a parallel block")
 			log.Logger.Error(err)
 			return err
 		}
@@ -232,69 +424,29 @@ func (s *ExperimentSpec) UnmarshalJSON(data []byte) error {
 				return e
 			}
 			tsk = rt
+		} else if t.Parallel != nil {
+			// this is a parallel block
+			log.Logger.Debug("found parallel block")
+			pt := &parallelTask{}
+			if err := json.Unmarshal(tBytes, pt); err != nil {
+				e := errors.New("json unmarshal error")
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return e
+			}
+			tsk = pt
 		} else {
 			// this is some other task
-			switch *t.Task {
-			case ReadinessTaskName:
-				rt := &readinessTask{}
-				if err := json.Unmarshal(tBytes, rt); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = rt
-			case CustomMetricsTaskName:
-				cdt := &customMetricsTask{}
-				if err := json.Unmarshal(tBytes, cdt); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = cdt
-			case CollectHTTPTaskName:
-				cht := &collectHTTPTask{}
-				if err := json.Unmarshal(tBytes, cht); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = cht
-			case CollectGRPCTaskName:
-				cgt := &collectGRPCTask{}
-				if err := json.Unmarshal(tBytes, cgt); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = cgt
-			case CollectABNMetricsTaskName:
-				cgt := &collectABNMetricsTask{}
-				if err := json.Unmarshal(tBytes, cgt); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = cgt
-			case AssessTaskName:
-				at := &assessTask{}
-				if err := json.Unmarshal(tBytes, at); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = at
-			case NotifyTaskName:
-				nt := &notifyTask{}
-				if err := json.Unmarshal(tBytes, nt); err != nil {
-					e := errors.New("json unmarshal error")
-					log.Logger.WithStackTrace(err.Error()).Error(e)
-					return e
-				}
-				tsk = nt
-			default:
+			factory, ok := getTaskFactory(*t.Task)
+			if !ok {
 				log.Logger.Error("unknown task: " + *t.Task)
 				return errors.New("unknown task: " + *t.Task)
 			}
+			tsk = factory()
+			if err := json.Unmarshal(tBytes, tsk); err != nil {
+				e := errors.New("json unmarshal error")
+				log.Logger.WithStackTrace(err.Error()).Error(e)
+				return e
+			}
 		}
 		n := append(*s, tsk)
 		*s = n
@@ -323,9 +475,39 @@ func metricTypeMatch(t MetricType, val interface{}) bool {
 
 // updateMetricValueScalar updates a scalar metric value for a given version
 func (in *Insights) updateMetricValueScalar(m string, i int, val float64) {
+	in.appendMetricValueScalar(m, i, val, in.loop)
+}
+
+// appendMetricValueScalar appends a scalar metric value observed in the given loop for a given version
+func (in *Insights) appendMetricValueScalar(m string, i int, val float64, loop int) {
+	if in.NonHistMetricLoops == nil {
+		in.NonHistMetricLoops = make([]map[string][]int, in.NumVersions)
+	}
+	if in.NonHistMetricLoops[i] == nil {
+		in.NonHistMetricLoops[i] = make(map[string][]int)
+	}
+	loops := in.NonHistMetricLoops[i][m]
+	// values observed before loops were recorded are attributed to loop 0
+	for len(loops) < len(in.NonHistMetricValues[i][m]) {
+		loops = append(loops, 0)
+	}
+	in.NonHistMetricLoops[i][m] = append(loops, loop)
 	in.NonHistMetricValues[i][m] = append(in.NonHistMetricValues[i][m], val)
 }
 
+// metricLoops returns the loops in which the values of the counter or gauge metric m were observed for version i
+// nil is returned if the loops are not known
+func (in *Insights) metricLoops(i int, m string) []int {
+	if i >= len(in.NonHistMetricLoops) || i >= len(in.NonHistMetricValues) {
+		return nil
+	}
+	loops := in.NonHistMetricLoops[i][m]
+	if len(loops) != len(in.NonHistMetricValues[i][m]) {
+		return nil
+	}
+	return loops
+}
+
 // updateMetricValueVector updates a vector metric value for a given version
 func (in *Insights) updateMetricValueVector(m string, i int, val []float64) {
 	in.NonHistMetricValues[i][m] = append(in.NonHistMetricValues[i][m], val...)
@@ -336,22 +518,55 @@ func (in *Insights) updateMetricValueHist(m string, i int, val []HistBucket) {
 	in.HistMetricValues[i][m] = append(in.HistMetricValues[i][m], val...)
 }
 
+// replaceMetricHist replaces the histogram of the given metric for version i, rather than adding to it
+// it is used for histograms that are cumulative or windowed snapshots, such as those reported by Prometheus,
+// which would be counted more than once if the histograms of every loop were merged
+func (in *Insights) replaceMetricHist(m string, mm MetricMeta, i int, val []HistBucket) error {
+	if i < len(in.HistMetricValues) {
+		delete(in.HistMetricValues[i], m)
+	}
+	return in.updateMetric(m, mm, i, val)
+}
+
 // updateSummaryMetric updates a summary metric value for a given version
 func (in *Insights) updateSummaryMetric(m string, i int, val *summarymetrics.SummaryMetric) {
 	in.SummaryMetricValues[i][m] = *val
 }
 
 // registerMetric registers a new metric by adding its meta data
+// PerLoop is not compared, since meta data stored before per-loop semantics were defined does not set it;
+// if PerLoop differs, the values of a counter are converted and the new meta data replaces the old
 func (in *Insights) registerMetric(m string, mm MetricMeta) error {
-	if old, ok := in.MetricsInfo[m]; ok && !reflect.DeepEqual(old, mm) {
-		err := fmt.Errorf("old and new metric meta for %v differ", m)
-		log.Logger.WithStackTrace(fmt.Sprintf("old: %v \nnew: %v", old, mm)).Error(err)
-		return err
+	if old, ok := in.MetricsInfo[m]; ok {
+		cmp := old
+		cmp.PerLoop = mm.PerLoop
+		if !reflect.DeepEqual(cmp, mm) {
+			err := fmt.Errorf("old and new metric meta for %v differ", m)
+			log.Logger.WithStackTrace(fmt.Sprintf("old: %v \nnew: %v", old, mm)).Error(err)
+			return err
+		}
+		if old.PerLoop != mm.PerLoop && mm.Type == CounterMetricType {
+			in.convertCounterValues(m, old)
+		}
 	}
 	in.MetricsInfo[m] = mm
 	return nil
 }
 
+// convertCounterValues replaces the values of counter m, stored with the old meta data, by a single value for each version
+// the value is the one reported with the old meta data, so that it is neither summed as per-loop counts nor replaced as a snapshot
+func (in *Insights) convertCounterValues(m string, old MetricMeta) {
+	for i, vals := range in.NonHistMetricValues {
+		if len(vals[m]) < 2 {
+			continue
+		}
+		if loops := in.metricLoops(i, m); loops != nil {
+			in.NonHistMetricLoops[i][m] = []int{loops[len(loops)-1]}
+		}
+		vals[m] = []float64{counterOrGaugeValue(old, vals[m])}
+	}
+}
+
 // updateMetric registers a metric and adds a metric value for a given version
 // metric names will be normalized
 func (in *Insights) updateMetric(m string, mm MetricMeta, i int, val interface{}) error {
@@ -450,6 +665,68 @@ func (in *Insights) TrackVersionStr(i int) string {
 	return in.VersionNames[i].Track + " (" + in.VersionNames[i].Version + ")"
 }
 
+// SLOLimitStr creates a string of the limit of an SLO for display purposes
+// Limits relative to the baseline version include the effective limit, if it is known
+func (in *Insights) SLOLimitStr(i int, upper bool) string {
+	var slo SLO
+	var effective []*float64
+	if upper {
+		slo = in.SLOs.Upper[i]
+		if in.EffectiveSLOLimits != nil {
+			effective = in.EffectiveSLOLimits.Upper
+		}
+	} else {
+		slo = in.SLOs.Lower[i]
+		if in.EffectiveSLOLimits != nil {
+			effective = in.EffectiveSLOLimits.Lower
+		}
+	}
+	if !slo.relative() {
+		return fmt.Sprint(slo.Limit)
+	}
+
+	baseline := in.TrackVersionStr(in.SLOs.Baseline)
+	var str string
+	if slo.Ratio != nil {
+		str = fmt.Sprintf("%v x %v", *slo.Ratio, baseline)
+	} else {
+		str = fmt.Sprintf("%v + %v", baseline, *slo.Delta)
+	}
+	if i < len(effective) && effective[i] != nil {
+		str = fmt.Sprintf("%v (%0.2f)", str, *effective[i])
+	}
+	return str
+}
+
+// overrideFor returns the limit that the SLO overrides for version j, if any
+func (in *Insights) overrideFor(slo SLO, j int) (float64, bool) {
+	if j >= len(in.VersionNames) {
+		return 0, false
+	}
+	if l, ok := slo.Overrides[in.VersionNames[j].Track]; ok && len(in.VersionNames[j].Track) > 0 {
+		return l, true
+	}
+	if l, ok := slo.Overrides[in.VersionNames[j].Version]; ok && len(in.VersionNames[j].Version) > 0 {
+		return l, true
+	}
+	return 0, false
+}
+
+// SLOVersionLimitStr creates a string of the limit of an SLO for version j for display purposes
+// An empty string is returned unless the SLO overrides the limit for version j
+func (in *Insights) SLOVersionLimitStr(i int, j int, upper bool) string {
+	var slo SLO
+	if upper {
+		slo = in.SLOs.Upper[i]
+	} else {
+		slo = in.SLOs.Lower[i]
+	}
+	if l, ok := in.overrideFor(slo, j); ok {
+		return fmt.Sprint(l)
+	}
+	return ""
+}
+
 // initializeSLOsSatisfied initializes the SLOs satisfied field
 func (exp *Experiment) initializeSLOsSatisfied() error {
 	if exp.Result.Insights.SLOsSatisfied != nil {
@@ -500,6 +777,8 @@ func (r *ExperimentResult) initInsightsWithNumVersions(n int) error {
 			NumVersions: n,
 		}
 	}
+	// metric values are observed in the current loop
+	r.Insights.loop = r.NumLoops
 	return r.Insights.initMetrics()
 }
 
@@ -557,7 +836,10 @@ func (in *Insights) getCounterOrGaugeMetricFromValuesMap(i int, m string) *float
 		if vals, ok := in.NonHistMetricValues[i][m]; ok {
 			log.Logger.Tracef("found metric value for version %v and metric %v", i, m)
 			if len(vals) > 0 {
-				return float64Pointer(vals[len(vals)-1])
+				if r := in.cumulativeRate(i, m); r != nil {
+					return r
+				}
+				return float64Pointer(counterOrGaugeValue(mm, vals))
 			}
 		}
 		log.Logger.Infof("could not find metric value for version %v and metric %v", i, m)
@@ -566,6 +848,87 @@ func (in *Insights) getCounterOrGaugeMetricFromValuesMap(i int, m string) *float
 	return nil
 }
 
+// counterOrGaugeValue computes the value of a counter or gauge metric from its observed values
+// per-loop counters are summed over loops; otherwise, the latest value is used
+func counterOrGaugeValue(mm MetricMeta, vals []float64) float64 {
+	if mm.PerLoop && mm.Type == CounterMetricType {
+		sum := 0.0
+		for _, v := range vals {
+			sum += v
+		}
+		return sum
+	}
+	return vals[len(vals)-1]
+}
+
+// cumulativeRate returns the rate of events for version i over all loops, if m is a per-loop rate gauge
+// the rate is computed from the per-loop counters of the events and requests; for example, http/error-rate is computed from http/error-count and http/request-count
+// nil is returned if m is not a per-loop rate gauge, if its counters are not per-loop counters, or if no requests were counted
+func (in *Insights) cumulativeRate(i int, m string) *float64 {
+	mm := in.MetricsInfo[m]
+	s := strings.Split(m, "/")
+	if !mm.PerLoop || mm.Type != GaugeMetricType || len(s) != 2 || !strings.HasSuffix(s[1], rateSuffix) {
+		return nil
+	}
+	events := s[0] + "/" + strings.TrimSuffix(s[1], rateSuffix) + countSuffix
+	requests := s[0] + "/" + requestCountMetric
+	for _, c := range []string{events, requests} {
+		if cm, ok := in.MetricsInfo[c]; !ok || !cm.PerLoop || cm.Type != CounterMetricType {
+			return nil
+		}
+	}
+	n := in.getCounterOrGaugeMetricFromValuesMap(i, requests)
+	x := in.getCounterOrGaugeMetricFromValuesMap(i, events)
+	if n == nil || x == nil || *n == 0 {
+		return nil
+	}
+	return float64Pointer(*x / *n)
+}
+
+// latestMetricValue returns the latest observed value of the given counter or gauge metric for version i
+// for per-loop counters, this is the count in the latest loop
+func (in *Insights) latestMetricValue(i int, m string) *float64 {
+	if i >= len(in.NonHistMetricValues) {
+		return nil
+	}
+	vals := in.NonHistMetricValues[i][m]
+	if len(vals) == 0 {
+		return nil
+	}
+	return float64Pointer(vals[len(vals)-1])
+}
+
+// LoopMetricValue returns the value of the counter or gauge metric m for version i as it was at the end of the given loop
+// The value of a per-loop gauge is its value in the given loop
+// nil is returned if the metric has no value in the loop, or if the loops in which its values were observed are not known
+func (in *Insights) LoopMetricValue(i int, m string, loop int) *float64 {
+	nm, err := NormalizeMetricName(m)
+	if err != nil {
+		return nil
+	}
+	mm, ok := in.MetricsInfo[nm]
+	if !ok || (mm.Type != CounterMetricType && mm.Type != GaugeMetricType) {
+		log.Logger.Errorf("metric %v is not a counter or gauge metric", m)
+		return nil
+	}
+	loops := in.metricLoops(i, nm)
+	if loops == nil {
+		log.Logger.Infof("loops of metric %v for version %v are not known", m, i)
+		return nil
+	}
+	vals := []float64{}
+	for k, l := range loops {
+		if l > loop || (mm.PerLoop && mm.Type == GaugeMetricType && l != loop) {
+			continue
+		}
+		vals = append(vals, in.NonHistMetricValues[i][nm][k])
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	return float64Pointer(counterOrGaugeValue(mm, vals))
+}
+
 // getSampleAggregation aggregates the given base metric for the given version (i) with the given aggregation (a)
 func (in *Insights) getSampleAggregation(i int, baseMetric string, a string) *float64 {
 	at := AggregationType(a)
@@ -685,8 +1048,11 @@ func (in *Insights) aggregateMetric(i int, m string) *float64 {
 		} else if m.Type == SummaryMetricType {
 			log.Logger.Tracef("metric %v used for aggregation is a summary metric", baseMetric)
 			return in.getSummaryAggregation(i, baseMetric, s[2])
+		} else if m.Type == HistogramMetricType {
+			log.Logger.Tracef("metric %v used for aggregation is a histogram metric", baseMetric)
+			return in.getHistogramAggregation(i, baseMetric, s[2])
 		}
-		log.Logger.Errorf("metric %v used for aggregation is not a sample or summary metric", baseMetric)
+		log.Logger.Errorf("metric %v used for aggregation is not a sample, summary or histogram metric", baseMetric)
 		return nil
 	}
 	log.Logger.Warnf("could not find metric %v used for aggregation", baseMetric)
@@ -879,8 +1245,19 @@ func (exp *Experiment) SLOs() bool {
 	return exp.Result.Insights.NumVersions == len(sby)
 }
 
+// Decision returns the outcome of sequential testing: promote, rollback, or continue
+// An empty string is returned if the experiment does not use sequential testing
+// Example: Decision() == "continue"
+func (exp *Experiment) Decision() string {
+	if exp == nil || exp.Result == nil {
+		return ""
+	}
+	return string(exp.Result.Decision)
+}
+
 // run the experiment
-func (exp *Experiment) run(driver Driver) error {
+// if ctx is done before all tasks complete, the experiment is marked as interrupted
+func (exp *Experiment) run(ctx context.Context, driver Driver) error {
 	var err error
 	exp.driver = driver
 	if exp.Result == nil {
@@ -894,35 +1271,200 @@ func (exp *Experiment) run(driver Driver) error {
 	exp.incrementNumLoops()
 	log.Logger.Debugf("experiment loop %d started ...", exp.Result.NumLoops)
 	exp.resetNumCompletedTasks()
+	exp.resetTaskResults()
 
 	err = driver.Write(exp)
 	if err != nil {
 		return err
 	}
 
-	log.Logger.Debugf("attempting to execute %v tasks", len(exp.Spec))
-	for i, t := range exp.Spec {
-		log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": started")
-		shouldRun := true
-		// if task has a condition
-		if cond := getIf(t); cond != nil {
-			// condition evaluates to false ... then shouldRun is false
-			program, err := expr.Compile(*cond, expr.Env(exp), expr.AsBool())
-			if err != nil {
-				log.Logger.WithStackTrace(err.Error()).Error("unable to compile if clause")
-				return err
-			}
+	return exp.runTasks(ctx, driver, 0)
+}
 
-			output, err := expr.Run(program, exp)
-			if err != nil {
-				log.Logger.WithStackTrace(err.Error()).Error("unable to run if clause")
-				return err
-			}
+// resume runs the experiment starting from its first incomplete or failed task
+// tasks that have already succeeded or were skipped in the latest loop are not run again,
+// and the insights they produced are kept;
+// metric values observed by the failed task were discarded when it failed, so they are not counted twice
+// an experiment that has not run yet is run from the start
+func (exp *Experiment) resume(ctx context.Context, driver Driver) error {
+	// there is nothing to resume if the experiment has not run yet
+	if exp.Result == nil {
+		exp.initResults(driver.GetRevision())
+	}
+	if exp.Result.NumLoops == 0 {
+		return exp.run(ctx, driver)
+	}
+
+	exp.driver = driver
+	start := exp.resumeIndex()
+	if start == len(exp.Spec) {
+		log.Logger.Info("all tasks in experiment are complete; nothing to resume")
+		return nil
+	}
+	log.Logger.Infof("resuming experiment loop %d from task %d", exp.Result.NumLoops, start+1)
+
+	// forget the outcome of the run that is being resumed
+	exp.Result.TaskResults = exp.Result.TaskResults[:start]
+	exp.Result.NumCompletedTasks = start
+	exp.Result.Failure = false
+	exp.Result.Interrupted = false
+
+	if err := driver.Write(exp); err != nil {
+		return err
+	}
+
+	return exp.runTasks(ctx, driver, start)
+}
+
+// resumeIndex returns the index of the first task that has not completed in the latest loop
+// task records are matched to tasks by position; a record for a different task is treated as incomplete
+func (exp *Experiment) resumeIndex() int {
+	i := 0
+	for ; i < len(exp.Spec) && i < len(exp.Result.TaskResults); i++ {
+		tr := exp.Result.TaskResults[i]
+		if tr.Name != *getName(exp.Spec[i]) {
+			break
+		}
+		if tr.Status != TaskSucceeded && tr.Status != TaskSkipped {
+			break
+		}
+	}
+	return i
+}
+
+// insightsMark records the metric values in the insights of a result at some point,
+// so that the values observed after that point can be discarded
+type insightsMark struct {
+	// insights is nil if the result had no insights
+	insights *Insights
+	// metricsInfo is a copy of the metrics meta data
+	metricsInfo map[string]MetricMeta
+	// nonHist, nonHistLoops and hist are copies of the metric values, which may be replaced rather than appended to
+	nonHist      []map[string][]float64
+	nonHistLoops []map[string][]int
+	hist         []map[string][]HistBucket
+	// summary is a copy of the summary metric values
+	summary []map[string]summarymetrics.SummaryMetric
+}
+
+// markInsights records the metric values in the insights of the result
+func (r *ExperimentResult) markInsights() *insightsMark {
+	if r == nil || r.Insights == nil {
+		return &insightsMark{}
+	}
+	in := r.Insights
+	mark := &insightsMark{
+		insights:    in,
+		metricsInfo: make(map[string]MetricMeta, len(in.MetricsInfo)),
+	}
+	for m, mm := range in.MetricsInfo {
+		mark.metricsInfo[m] = mm
+	}
+	for _, vals := range in.NonHistMetricValues {
+		mark.nonHist = append(mark.nonHist, copyValues(vals))
+	}
+	for _, loops := range in.NonHistMetricLoops {
+		mark.nonHistLoops = append(mark.nonHistLoops, copyValues(loops))
+	}
+	for _, vals := range in.HistMetricValues {
+		mark.hist = append(mark.hist, copyValues(vals))
+	}
+	for _, vals := range in.SummaryMetricValues {
+		summary := make(map[string]summarymetrics.SummaryMetric, len(vals))
+		for m, val := range vals {
+			summary[m] = val
+		}
+		mark.summary = append(mark.summary, summary)
+	}
+	return mark
+}
+
+// rollbackInsights discards the metrics and metric values added to the insights of the result after mark was recorded
+// other insights, such as assessments, are recomputed by the tasks that produce them, and are left as they are
+func (r *ExperimentResult) rollbackInsights(mark *insightsMark) {
+	if r == nil {
+		return
+	}
+	if mark.insights == nil || r.Insights != mark.insights {
+		r.Insights = mark.insights
+	}
+	in := r.Insights
+	if in == nil {
+		return
+	}
+	in.MetricsInfo = mark.metricsInfo
+	for i := range in.NonHistMetricValues {
+		in.NonHistMetricValues[i] = valuesAt(mark.nonHist, i)
+	}
+	for i := range in.NonHistMetricLoops {
+		in.NonHistMetricLoops[i] = valuesAt(mark.nonHistLoops, i)
+	}
+	for i := range in.HistMetricValues {
+		in.HistMetricValues[i] = valuesAt(mark.hist, i)
+	}
+	for i := range in.SummaryMetricValues {
+		in.SummaryMetricValues[i] = map[string]summarymetrics.SummaryMetric{}
+		if i < len(mark.summary) {
+			in.SummaryMetricValues[i] = mark.summary[i]
+		}
+	}
+}
+
+// copyValues returns a copy of the values of each metric
+// the copied slices are capped, so that values appended later do not overwrite them
+func copyValues[V any](vals map[string][]V) map[string][]V {
+	c := make(map[string][]V, len(vals))
+	for m, v := range vals {
+		c[m] = v[:len(v):len(v)]
+	}
+	return c
+}
+
+// valuesAt returns the copied values of metrics for version i, or no values if they were not recorded
+func valuesAt[V any](marks []map[string][]V, i int) map[string][]V {
+	if i < len(marks) {
+		return marks[i]
+	}
+	return map[string][]V{}
+}
 
-			shouldRun = output.(bool)
+// runTasks runs the tasks in the experiment spec starting from the task with the given index
+func (exp *Experiment) runTasks(ctx context.Context, driver Driver, start int) error {
+	log.Logger.Debugf("attempting to execute %v tasks", len(exp.Spec)-start)
+	for i := start; i < len(exp.Spec); i++ {
+		t := exp.Spec[i]
+		if ctx.Err() != nil {
+			return exp.interrupt(driver, ctx.Err())
+		}
+		log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": started")
+		tr := TaskResult{
+			Name:      *getName(t),
+			StartTime: time.Now(),
+		}
+		// if task has a condition that evaluates to false ... then shouldRun is false
+		shouldRun, err := evaluateIf(t, exp)
+		if err != nil {
+			log.Logger.Error("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": " + "condition could not be evaluated")
+			exp.addTaskResult(tr, TaskFailed, err)
+			exp.failExperiment()
+			if e := driver.Write(exp); e != nil {
+				return e
+			}
+			return err
 		}
 		if shouldRun {
-			err = t.run(exp)
+			tr.Attempts, err = runTaskWithOutputs(ctx, t, exp)
+			// the records of the tasks in a parallel block are kept with the record of the block
+			if pt, ok := t.(*parallelTask); ok {
+				tr.Parallel = pt.taskResults
+			}
+			if err != nil {
+				exp.addTaskResult(tr, TaskFailed, err)
+			}
+			if err != nil && ctx.Err() != nil {
+				log.Logger.Error("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": " + "interrupted")
+				return exp.interrupt(driver, ctx.Err())
+			}
 			if err != nil {
 				log.Logger.Error("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": " + "failure")
 				exp.failExperiment()
@@ -932,8 +1474,10 @@ func (exp *Experiment) run(driver Driver) error {
 				}
 				return err
 			}
+			exp.addTaskResult(tr, TaskSucceeded, nil)
 			log.Logger.Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": " + "completed")
 		} else {
+			exp.addTaskResult(tr, TaskSkipped, nil)
 			log.Logger.WithStackTrace(fmt.Sprint("false condition: ", *getIf(t))).Info("task " + fmt.Sprintf("%v: %v", i+1, *getName(t)) + ": " + "skipped")
 		}
 
@@ -944,7 +1488,7 @@ func (exp *Experiment) run(driver Driver) error {
 			return err
 		}
 	}
-	return nil
+	return exp.recordHistory(driver)
 }
 
 // failExperiment sets the experiment failure status to true
@@ -952,6 +1496,18 @@ func (exp *Experiment) failExperiment() {
 	exp.Result.Failure = true
 }
 
+// interrupt marks the experiment as interrupted and failed, and writes the final result
+// the cause of the interruption is returned unless the result could not be written
+func (exp *Experiment) interrupt(driver Driver, cause error) error {
+	log.Logger.WithStackTrace(cause.Error()).Error("experiment interrupted")
+	exp.Result.Interrupted = true
+	exp.failExperiment()
+	if err := driver.Write(exp); err != nil {
+		return err
+	}
+	return cause
+}
+
 // incrementNumCompletedTasks increments the number of completed tasks in the experiment
 func (exp *Experiment) incrementNumCompletedTasks() {
 	exp.Result.NumCompletedTasks++
@@ -961,32 +1517,76 @@ func (exp *Experiment) resetNumCompletedTasks() {
 	exp.Result.NumCompletedTasks = 0
 }
 
+// resetTaskResults clears the task records from the previous experiment loop
+func (exp *Experiment) resetTaskResults() {
+	exp.Result.TaskResults = nil
+}
+
+// addTaskResult completes the record of a task with its outcome and adds it to the experiment result
+func (exp *Experiment) addTaskResult(tr TaskResult, status TaskStatus, err error) {
+	exp.Result.TaskResults = append(exp.Result.TaskResults, completeTaskResult(tr, status, err))
+}
+
+// completeTaskResult completes the record of a task with its outcome
+func completeTaskResult(tr TaskResult, status TaskStatus, err error) TaskResult {
+	tr.EndTime = time.Now()
+	tr.Duration = tr.EndTime.Sub(tr.StartTime).String()
+	tr.Status = status
+	if err != nil {
+		tr.Error = err.Error()
+	}
+	return tr
+}
+
 // incrementNumLoops increments the number of loops (experiment iterations)
 func (exp *Experiment) incrementNumLoops() {
 	exp.Result.NumLoops++
 }
 
-// getIf returns the condition (if any) which determine
-// whether of not if this task needs to run
-func getIf(t Task) *string {
+// getTaskMeta returns the fields common to all tasks for this task
+func getTaskMeta(t Task) TaskMeta {
 	var jsonBytes []byte
 	var tm TaskMeta
 	// convert t to jsonBytes
 	jsonBytes, _ = json.Marshal(t)
 	// convert jsonBytes to TaskMeta
 	_ = json.Unmarshal(jsonBytes, &tm)
-	return tm.If
+	return tm
+}
+
+// getIf returns the condition (if any) which determine
+// whether of not if this task needs to run
+func getIf(t Task) *string {
+	return getTaskMeta(t).If
+}
+
+// evaluateIf evaluates the condition (if any) of a task against the experiment
+// it returns true if the task needs to run
+func evaluateIf(t Task, exp *Experiment) (bool, error) {
+	cond := getIf(t)
+	if cond == nil {
+		return true, nil
+	}
+	program, err := expr.Compile(*cond, conditionOptions(exp)...)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to compile if clause")
+		return false, err
+	}
+
+	output, err := expr.Run(program, exp)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to run if clause")
+		return false, err
+	}
+	return output.(bool), nil
 }
 
 // getName returns the name of this task
 func getName(t Task) *string {
-	var jsonBytes []byte
-	var tm TaskMeta
-	// convert t to jsonBytes
-	jsonBytes, _ = json.Marshal(t)
-	// convert jsonBytes to TaskMeta
-	_ = json.Unmarshal(jsonBytes, &tm)
-
+	if _, ok := t.(*parallelTask); ok {
+		return StringPointer(ParallelTaskName)
+	}
+	tm := getTaskMeta(t)
 	if tm.Task == nil {
 		if tm.Run != nil {
 			return StringPointer(RunTaskName)
@@ -1008,7 +1608,8 @@ func BuildExperiment(driver Driver) (*Experiment, error) {
 }
 
 // RunExperiment runs an experiment
-func RunExperiment(reuseResult bool, driver Driver) error {
+// Cancelling ctx stops the experiment; the result written last marks the run as interrupted
+func RunExperiment(ctx context.Context, reuseResult bool, driver Driver) error {
 	var exp *Experiment
 	var err error
 	if exp, err = BuildExperiment(driver); err != nil {
@@ -1017,5 +1618,17 @@ func RunExperiment(reuseResult bool, driver Driver) error {
 	if !reuseResult {
 		exp.initResults(driver.GetRevision())
 	}
-	return exp.run(driver)
+	return exp.run(ctx, driver)
+}
+
+// ResumeExperiment resumes an experiment that did not complete, such as one that failed or was interrupted
+// Execution starts from the first incomplete or failed task in the latest loop, as recorded in the experiment result
+// Insights produced by earlier tasks are kept
+func ResumeExperiment(ctx context.Context, driver Driver) error {
+	var exp *Experiment
+	var err error
+	if exp, err = BuildExperiment(driver); err != nil {
+		return err
+	}
+	return exp.resume(ctx, driver)
 }
@@ -1,9 +1,12 @@
 package base
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"fortio.org/fortio/fhttp"
 	"github.com/iter8-tools/iter8/base/log"
@@ -86,13 +89,13 @@ func TestRunningTasks(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := ct.run(exp)
+	err := ct.Run(context.Background(), exp)
 	assert.NoError(t, err)
 	assert.Equal(t, exp.Result.Insights.NumVersions, 1)
 	// sanity check -- handler was called
 	assert.True(t, verifyHandlerCalled)
 
-	err = at.run(exp)
+	err = at.Run(context.Background(), exp)
 	assert.NoError(t, err)
 
 	// SLOs should be satisfied by app
@@ -120,7 +123,7 @@ func TestRunExperiment(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 4, len(e.Spec))
 
-	err = RunExperiment(false, &mockDriver{e})
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
 	assert.NoError(t, err)
 	// sanity check -- handler was called
 	assert.True(t, verifyHandlerCalled)
@@ -142,3 +145,194 @@ func TestFailExperiment(t *testing.T) {
 	exp.failExperiment()
 	assert.False(t, exp.NoFailure())
 }
+
+func TestInterruptExperiment(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run: StringPointer("sleep 10"),
+		},
+	}
+	exp := &Experiment{
+		Spec: ExperimentSpec{rt, rt},
+	}
+	exp.initResults(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	md := &mockDriver{exp}
+	err := RunExperiment(ctx, false, md)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the last written result marks the run as interrupted
+	assert.True(t, md.Experiment.Result.Interrupted)
+	assert.False(t, md.Experiment.NoFailure())
+	assert.False(t, md.Experiment.Completed())
+	assert.Equal(t, 0, md.Experiment.Result.NumCompletedTasks)
+}
+
+func TestTaskResults(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- run: echo hello
+- run: exit 1
+  if: "false"
+- run: exit 1
+  retries: 1
+  backoff: 10ms
+`), e)
+	assert.NoError(t, err)
+
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.Error(t, err)
+
+	trs := e.Result.TaskResults
+	assert.Equal(t, 3, len(trs))
+	assert.Equal(t, RunTaskName, trs[0].Name)
+	assert.Equal(t, TaskSucceeded, trs[0].Status)
+	assert.Equal(t, 1, trs[0].Attempts)
+	assert.Empty(t, trs[0].Error)
+	assert.Equal(t, TaskSkipped, trs[1].Status)
+	assert.Equal(t, 0, trs[1].Attempts)
+	assert.Equal(t, TaskFailed, trs[2].Status)
+	assert.Equal(t, 2, trs[2].Attempts)
+	assert.NotEmpty(t, trs[2].Error)
+	assert.False(t, trs[2].EndTime.Before(trs[2].StartTime))
+	_, err = time.ParseDuration(trs[2].Duration)
+	assert.NoError(t, err)
+
+	// task results are reset in each loop
+	e.Spec = e.Spec[:1]
+	err = RunExperiment(context.Background(), true, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(e.Result.TaskResults))
+}
+
+func TestTaskResultsInvalidCondition(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- run: echo hello
+- run: echo world
+  if: "Result.NumLoops >"
+`), e)
+	assert.NoError(t, err)
+
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.Error(t, err)
+	assert.False(t, e.NoFailure())
+
+	// the task whose condition could not be evaluated is recorded as failed
+	trs := e.Result.TaskResults
+	assert.Equal(t, 2, len(trs))
+	assert.Equal(t, TaskSucceeded, trs[0].Status)
+	assert.Equal(t, TaskFailed, trs[1].Status)
+	assert.Equal(t, 0, trs[1].Attempts)
+	assert.Equal(t, err.Error(), trs[1].Error)
+}
+
+func TestResumeExperiment(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- run: echo a >> log.txt
+- run: exit 1
+  if: "false"
+- run: test -f ok.txt && echo b >> log.txt
+`), e)
+	assert.NoError(t, err)
+
+	// resuming an experiment that has not run yet runs it from the start
+	md := &mockDriver{e}
+	err = ResumeExperiment(context.Background(), md)
+	assert.Error(t, err)
+	assert.False(t, e.NoFailure())
+	assert.Equal(t, 1, e.Result.NumLoops)
+	assert.Equal(t, 2, e.Result.NumCompletedTasks)
+
+	// insights produced by completed tasks are kept
+	assert.NoError(t, e.Result.initInsightsWithNumVersions(1))
+	assert.NoError(t, e.Result.Insights.updateMetric("a/counter", MetricMeta{Type: CounterMetricType}, 0, float64(10)))
+
+	// resume from the failed task
+	assert.NoError(t, os.WriteFile("ok.txt", []byte{}, 0600))
+	err = ResumeExperiment(context.Background(), md)
+	assert.NoError(t, err)
+	assert.True(t, e.Completed())
+	assert.True(t, e.NoFailure())
+	assert.Equal(t, 1, e.Result.NumLoops)
+	assert.Equal(t, 3, len(e.Result.TaskResults))
+	assert.Equal(t, TaskSkipped, e.Result.TaskResults[1].Status)
+	assert.Equal(t, TaskSucceeded, e.Result.TaskResults[2].Status)
+	assert.Equal(t, float64(10), *e.Result.Insights.ScalarMetricValue(0, "a/counter"))
+	b, err := os.ReadFile("log.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nb\n", string(b))
+
+	// nothing to resume
+	err = ResumeExperiment(context.Background(), md)
+	assert.NoError(t, err)
+	b, _ = os.ReadFile("log.txt")
+	assert.Equal(t, "a\nb\n", string(b))
+}
+
+// partialTask records metric values, and then fails unless it is told to succeed
+type partialTask struct {
+	customTask
+	succeed bool
+}
+
+func (t *partialTask) Run(ctx context.Context, exp *Experiment) error {
+	if err := exp.Result.initInsightsWithNumVersions(1); err != nil {
+		return err
+	}
+	in := exp.Result.Insights
+	_ = in.updateMetric("a/counter", MetricMeta{Type: CounterMetricType}, 0, float64(5))
+	_ = in.updateMetric("a/hist", MetricMeta{Type: HistogramMetricType}, 0, []HistBucket{{Lower: 0, Upper: 1, Count: 5}})
+	_ = in.replaceMetricHist("a/snapshot", MetricMeta{Type: HistogramMetricType}, 0, []HistBucket{{Lower: 0, Upper: 1, Count: 7}})
+	if !t.succeed {
+		return errors.New("task failed after recording metrics")
+	}
+	return nil
+}
+
+func TestResumeExperimentAfterPartialFailure(t *testing.T) {
+	pt := &partialTask{}
+	pt.Task = StringPointer("partial")
+	e := &Experiment{
+		Spec: []Task{pt},
+	}
+	e.initResults(1)
+	assert.NoError(t, e.Result.initInsightsWithNumVersions(1))
+	// recorded by an earlier task
+	assert.NoError(t, e.Result.Insights.updateMetric("a/counter", MetricMeta{Type: CounterMetricType}, 0, float64(10)))
+	snapshot := []HistBucket{{Lower: 0, Upper: 1, Count: 3}}
+	assert.NoError(t, e.Result.Insights.replaceMetricHist("a/snapshot", MetricMeta{Type: HistogramMetricType}, 0, snapshot))
+	e.Result.NumLoops = 1
+	e.Result.TaskResults = []TaskResult{{Name: "partial", Status: TaskFailed}}
+
+	// the values recorded by the failed task are discarded
+	md := &mockDriver{e}
+	assert.Error(t, ResumeExperiment(context.Background(), md))
+	in := e.Result.Insights
+	assert.Equal(t, []float64{10}, in.NonHistMetricValues[0]["a/counter"])
+	assert.NotContains(t, in.MetricsInfo, "a/hist")
+	assert.NotContains(t, in.HistMetricValues[0], "a/hist")
+	assert.Equal(t, snapshot, in.HistMetricValues[0]["a/snapshot"])
+
+	// and are counted once when the task is resumed
+	pt.succeed = true
+	assert.NoError(t, ResumeExperiment(context.Background(), md))
+	assert.True(t, e.Completed())
+	in = e.Result.Insights
+	assert.Equal(t, []float64{10, 5}, in.NonHistMetricValues[0]["a/counter"])
+	assert.Len(t, in.HistMetricValues[0]["a/hist"], 1)
+	assert.Equal(t, []HistBucket{{Lower: 0, Upper: 1, Count: 7}}, in.HistMetricValues[0]["a/snapshot"])
+}
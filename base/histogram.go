@@ -0,0 +1,226 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// histBin is a bucket of a merged histogram; counts are fractional once buckets are split
+type histBin struct {
+	lower float64
+	upper float64
+	count float64
+}
+
+// mergeHistBuckets merges histogram buckets into non-overlapping bins sorted by their endpoints
+// Buckets may overlap when they are collected in multiple loops, or by multiple tasks, with different boundaries
+// The count of a bucket is assumed to be spread uniformly over the bucket, and is split accordingly
+func mergeHistBuckets(buckets []HistBucket) []histBin {
+	// endpoints of the bins
+	points := []float64{}
+	for _, b := range buckets {
+		points = append(points, b.Lower, b.Upper)
+	}
+	sort.Float64s(points)
+	edges := []float64{}
+	for _, p := range points {
+		if len(edges) == 0 || edges[len(edges)-1] != p {
+			edges = append(edges, p)
+		}
+	}
+
+	// counts[k] is the count of the bin between edges[k] and edges[k+1]
+	counts := make([]float64, len(edges))
+	// pointCounts[k] is the count of buckets whose endpoints both equal edges[k]
+	pointCounts := make([]float64, len(edges))
+	for _, b := range buckets {
+		if b.Count == 0 {
+			continue
+		}
+		lo := sort.SearchFloat64s(edges, b.Lower)
+		if b.Upper == b.Lower {
+			pointCounts[lo] += float64(b.Count)
+			continue
+		}
+		for k := lo; k < len(edges)-1 && edges[k] < b.Upper; k++ {
+			counts[k] += float64(b.Count) * (edges[k+1] - edges[k]) / (b.Upper - b.Lower)
+		}
+	}
+
+	bins := []histBin{}
+	for k := range edges {
+		if pointCounts[k] > 0 {
+			bins = append(bins, histBin{lower: edges[k], upper: edges[k], count: pointCounts[k]})
+		}
+		if k < len(edges)-1 && counts[k] > 0 {
+			bins = append(bins, histBin{lower: edges[k], upper: edges[k+1], count: counts[k]})
+		}
+	}
+	return bins
+}
+
+// histCount returns the number of observations in the histogram
+func histCount(bins []histBin) float64 {
+	n := 0.0
+	for _, b := range bins {
+		n += b.count
+	}
+	return n
+}
+
+// histMoments returns the mean and sample variance of the observations in the histogram
+// Observations are assumed to be spread uniformly within each bin
+func histMoments(bins []histBin) (float64, float64) {
+	n, sum, sumSquares := 0.0, 0.0, 0.0
+	for _, b := range bins {
+		n += b.count
+		sum += b.count * (b.lower + b.upper) / 2
+		sumSquares += b.count * (b.lower*b.lower + b.lower*b.upper + b.upper*b.upper) / 3
+	}
+	mean := sum / n
+	if n < 2 {
+		return mean, 0
+	}
+	return mean, math.Max(0, (sumSquares-n*mean*mean)/(n-1))
+}
+
+// histPercentile returns the given percentile of the observations in the histogram
+// The value is interpolated linearly within the bin containing the percentile
+func histPercentile(bins []histBin, percent float64) float64 {
+	rank := percent / 100 * histCount(bins)
+	seen := 0.0
+	for _, b := range bins {
+		if seen+b.count >= rank {
+			return b.lower + (b.upper-b.lower)*(rank-seen)/b.count
+		}
+		seen += b.count
+	}
+	return bins[len(bins)-1].upper
+}
+
+// getHistogramAggregation aggregates the given base metric for the given version (i) with the given aggregation (a)
+// Buckets from multiple loops are merged before they are aggregated
+func (in *Insights) getHistogramAggregation(i int, baseMetric string, a string) *float64 {
+	if i >= len(in.HistMetricValues) {
+		log.Logger.Warnf("metric values not found for version %v; initialized for %v versions", i, len(in.HistMetricValues))
+		return nil
+	}
+	bins := mergeHistBuckets(in.HistMetricValues[i][baseMetric])
+	if len(bins) == 0 {
+		log.Logger.Infof("metric %v for version %v has no observations", baseMetric, i)
+		return nil
+	}
+
+	switch AggregationType(a) {
+	case CountAggregator:
+		return float64Pointer(histCount(bins))
+	case MeanAggregator:
+		mean, _ := histMoments(bins)
+		return float64Pointer(mean)
+	case StdDevAggregator:
+		_, variance := histMoments(bins)
+		return float64Pointer(math.Sqrt(variance))
+	case MinAggregator:
+		return float64Pointer(bins[0].lower)
+	case MaxAggregator:
+		return float64Pointer(bins[len(bins)-1].upper)
+	default: // don't do anything
+	}
+
+	// at this point, 'a' must be a percentile aggregator
+	if strings.HasPrefix(a, PercentileAggregatorPrefix) {
+		b := strings.TrimPrefix(a, PercentileAggregatorPrefix)
+		if match, _ := regexp.MatchString(decimalRegex, b); match {
+			percent, err := strconv.ParseFloat(b, 64)
+			if err != nil || percent > 100 {
+				log.Logger.Errorf("error extracting percent from aggregation func %v", a)
+				return nil
+			}
+			return float64Pointer(histPercentile(bins, percent))
+		}
+		log.Logger.Errorf("unable to extract percent from agggregation func %v", a)
+		return nil
+	}
+	log.Logger.Errorf("invalid aggregation %v", a)
+	return nil
+}
+
+// histBucketsFromValue converts the value extracted from a metrics database into histogram buckets
+// The value is a list of buckets, each of which is either
+//
+//	{"lower": 0, "upper": 10, "count": 5}, or
+//	{"le": "10", "count": "5"}, where counts are cumulative as in Prometheus histograms
+//
+// The lowest Prometheus bucket is assumed to start at 0,
+// and the count of the +Inf bucket is attributed to the largest finite upper endpoint
+func histBucketsFromValue(val interface{}) ([]HistBucket, error) {
+	items, ok := val.([]interface{})
+	if !ok {
+		return nil, errors.New("histogram value must be a list of buckets")
+	}
+	buckets := []HistBucket{}
+	// cumulative buckets in the Prometheus format
+	type leBucket struct {
+		le    float64
+		count float64
+	}
+	les := []leBucket{}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("histogram bucket %v must be an object", item)
+		}
+		count, err := parseHistNumber(m["count"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid count in histogram bucket %v", item)
+		}
+		if le, ok := m["le"]; ok {
+			l, err := parseHistNumber(le)
+			if err != nil {
+				return nil, fmt.Errorf("invalid le in histogram bucket %v", item)
+			}
+			les = append(les, leBucket{le: l, count: count})
+			continue
+		}
+		lower, err1 := parseHistNumber(m["lower"])
+		upper, err2 := parseHistNumber(m["upper"])
+		if err1 != nil || err2 != nil || upper < lower {
+			return nil, fmt.Errorf("invalid endpoints in histogram bucket %v", item)
+		}
+		buckets = append(buckets, HistBucket{Lower: lower, Upper: upper, Count: uint64(math.Round(count))})
+	}
+
+	sort.Slice(les, func(a, b int) bool { return les[a].le < les[b].le })
+	lower, seen := 0.0, 0.0
+	for _, b := range les {
+		upper := b.le
+		if math.IsInf(upper, 1) {
+			// attribute the count to the largest finite endpoint
+			upper = lower
+		}
+		if c := math.Round(b.count - seen); c > 0 {
+			buckets = append(buckets, HistBucket{Lower: math.Min(lower, upper), Upper: upper, Count: uint64(c)})
+		}
+		lower, seen = upper, math.Max(seen, b.count)
+	}
+	return buckets, nil
+}
+
+// parseHistNumber parses a number in a histogram bucket, which may be a JSON number or a string
+func parseHistNumber(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("%v is not a number", v)
+	}
+}
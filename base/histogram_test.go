@@ -0,0 +1,109 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeHistBuckets(t *testing.T) {
+	// buckets from two loops with different boundaries
+	bins := mergeHistBuckets([]HistBucket{
+		{Lower: 0, Upper: 10, Count: 10},
+		{Lower: 10, Upper: 20, Count: 0},
+		{Lower: 5, Upper: 15, Count: 4},
+		{Lower: 15, Upper: 15, Count: 1},
+	})
+	assert.Equal(t, []histBin{
+		{lower: 0, upper: 5, count: 5},
+		{lower: 5, upper: 10, count: 7},
+		{lower: 10, upper: 15, count: 2},
+		{lower: 15, upper: 15, count: 1},
+	}, bins)
+	assert.Equal(t, 15.0, histCount(bins))
+
+	assert.Empty(t, mergeHistBuckets(nil))
+}
+
+func TestHistogramAggregation(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	exp := &Experiment{}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	in := exp.Result.Insights
+
+	mm := MetricMeta{Type: HistogramMetricType}
+	assert.NoError(t, in.updateMetric("a/latency", mm, 0, []HistBucket{
+		{Lower: 0, Upper: 10, Count: 50},
+		{Lower: 10, Upper: 20, Count: 50},
+	}))
+	// buckets of a later loop are merged
+	assert.NoError(t, in.updateMetric("a/latency", mm, 0, []HistBucket{
+		{Lower: 0, Upper: 10, Count: 50},
+		{Lower: 10, Upper: 20, Count: 50},
+	}))
+	assert.NoError(t, in.updateMetric("a/latency", mm, 1, []HistBucket{
+		{Lower: 20, Upper: 40, Count: 10},
+	}))
+
+	for _, c := range []struct {
+		metric string
+		want   float64
+	}{
+		{metric: "a/latency/count", want: 200},
+		{metric: "a/latency/mean", want: 10},
+		{metric: "a/latency/min", want: 0},
+		{metric: "a/latency/max", want: 20},
+		{metric: "a/latency/p50", want: 10},
+		{metric: "a/latency/p75", want: 15},
+		{metric: "a/latency/p99", want: 19.8},
+		{metric: "a/latency/p0", want: 0},
+	} {
+		v := in.ScalarMetricValue(0, c.metric)
+		if assert.NotNil(t, v, c.metric) {
+			assert.InDelta(t, c.want, *v, 1e-9, c.metric)
+		}
+	}
+	// uniform within buckets
+	assert.InDelta(t, 5.7879, *in.ScalarMetricValue(0, "a/latency/stddev"), 1e-3)
+	assert.InDelta(t, 39.0, *in.ScalarMetricValue(1, "a/latency/p95"), 1e-9)
+	assert.Nil(t, in.ScalarMetricValue(0, "a/latency/p101"))
+	assert.Nil(t, in.ScalarMetricValue(0, "a/latency/median"))
+
+	// histogram metrics can be used in SLOs
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			SLOs: &SLOLimits{
+				Upper: []SLO{{Metric: "a/latency/p99", Limit: 30}},
+			},
+		},
+	}
+	exp.Spec = []Task{task}
+	assert.NoError(t, task.Run(context.Background(), exp))
+	assert.Equal(t, []bool{true, false}, in.SLOsSatisfied.Upper[0])
+}
+
+func TestHistBucketsFromValue(t *testing.T) {
+	buckets, err := histBucketsFromValue([]interface{}{
+		map[string]interface{}{"lower": 0.0, "upper": 1.0, "count": 3.0},
+		map[string]interface{}{"lower": "1", "upper": "2", "count": "4"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []HistBucket{{Lower: 0, Upper: 1, Count: 3}, {Lower: 1, Upper: 2, Count: 4}}, buckets)
+
+	for _, val := range []interface{}{
+		1.0,
+		[]interface{}{"bucket"},
+		[]interface{}{map[string]interface{}{"lower": 0.0, "upper": 1.0}},
+		[]interface{}{map[string]interface{}{"lower": 2.0, "upper": 1.0, "count": 1.0}},
+		[]interface{}{map[string]interface{}{"le": "abc", "count": 1.0}},
+	} {
+		_, err = histBucketsFromValue(val)
+		assert.Error(t, err, val)
+	}
+}
@@ -0,0 +1,308 @@
+package base
+
+import (
+	"errors"
+	"math"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/montanaflynn/stats"
+	"helm.sh/helm/v3/pkg/time"
+)
+
+const (
+	// maxHistoryRuns is the number of most recent runs kept in the history of an experiment
+	maxHistoryRuns = 50
+
+	// defaultRegressionRuns is the default number of earlier runs that versions are compared against
+	defaultRegressionRuns = 5
+)
+
+// HistoryDriver is a driver that also stores the history of completed runs of an experiment
+// The history is kept across revisions of the experiment, each of which starts with a fresh result
+type HistoryDriver interface {
+	// ReadHistory reads the history; an empty history is returned if none has been written
+	ReadHistory() (*History, error)
+
+	// WriteHistory writes the history
+	WriteHistory(h *History) error
+}
+
+// History is the record of completed runs of an experiment
+type History struct {
+	// Runs are ordered from the oldest to the most recent
+	Runs []HistoryRun `json:"runs" yaml:"runs"`
+}
+
+// HistoryRun records the scalar metrics of a completed run of an experiment
+type HistoryRun struct {
+	// Revision is the revision of the experiment
+	Revision int `json:"revision" yaml:"revision"`
+
+	// StartTime is the time when the run started
+	StartTime time.Time `json:"startTime" yaml:"startTime"`
+
+	// Metrics[j] maps metric names to their values for version j
+	Metrics []map[string]float64 `json:"metrics" yaml:"metrics"`
+}
+
+// Regression configures the detection of regressions of versions compared with earlier runs of the experiment
+// Earlier runs are read from the history kept by the driver; completed runs are added to the history
+//
+// A metric of a version regresses if its value is significantly worse than its values in earlier runs.
+// The value is compared with the prediction interval of the earlier values using Student's t distribution.
+type Regression struct {
+	// Runs is the number of most recent runs that versions are compared against
+	// Default value is 5
+	Runs *int `json:"runs,omitempty" yaml:"runs,omitempty"`
+
+	// Confidence is the confidence level required for a regression to be significant
+	// Default value is 0.95
+	Confidence *float64 `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+
+	// Max are metrics for which higher values are better; a significant decrease is a regression
+	Max []string `json:"max,omitempty" yaml:"max,omitempty"`
+
+	// Min are metrics for which lower values are better; a significant increase is a regression
+	Min []string `json:"min,omitempty" yaml:"min,omitempty"`
+}
+
+// RegressionResults are the results of comparing versions with earlier runs of the experiment
+type RegressionResults struct {
+	// Runs is the number of earlier runs that versions were compared against
+	Runs int `json:"runs" yaml:"runs"`
+
+	// Metrics are the compared metrics; max metrics are followed by min metrics
+	Metrics []string `json:"metrics" yaml:"metrics"`
+
+	// History[i][j] is the mean of metric i for version j in the earlier runs
+	// it is nil if the metric has fewer than two values in the earlier runs
+	History [][]*float64 `json:"history" yaml:"history"`
+
+	// PValues[i][j] is the one-sided p-value of the test for a regression of metric i for version j
+	// it is nil if the metric was not tested
+	PValues [][]*float64 `json:"pValues" yaml:"pValues"`
+
+	// Regressed[i][j] is true if metric i of version j regressed significantly
+	Regressed [][]bool `json:"regressed" yaml:"regressed"`
+}
+
+// Any returns true if any metric of any version regressed
+func (r *RegressionResults) Any() bool {
+	for i := range r.Regressed {
+		for _, regressed := range r.Regressed[i] {
+			if regressed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate checks the regression configuration located at the given path
+func (r *Regression) validate(errs *inputErrors, path string) {
+	if r.Runs != nil && *r.Runs < 2 {
+		errs.add(joinPath(path, "runs"), "at least two runs are required")
+	}
+	if c := r.Confidence; c != nil && (*c <= 0 || *c >= 1) {
+		errs.add(joinPath(path, "confidence"), "confidence must be between 0 and 1")
+	}
+	if len(r.Max)+len(r.Min) == 0 {
+		errs.add(path, "at least one max or min metric is required")
+	}
+}
+
+// metrics returns the metrics compared by the regression check; max metrics are followed by min metrics
+func (r *Regression) metrics() []string {
+	return append(append([]string{}, r.Max...), r.Min...)
+}
+
+// evaluateRegression compares the metrics of each version with the runs in the history
+// The current run is not compared against itself
+func evaluateRegression(exp *Experiment, r *Regression, h *History) *RegressionResults {
+	in := exp.Result.Insights
+	runs := defaultRegressionRuns
+	if r.Runs != nil {
+		runs = *r.Runs
+	}
+	confidence := defaultRewardConfidence
+	if r.Confidence != nil {
+		confidence = *r.Confidence
+	}
+
+	// the most recent earlier runs
+	earlier := []HistoryRun{}
+	for k := len(h.Runs) - 1; k >= 0 && len(earlier) < runs; k-- {
+		if exp.Result.isRun(h.Runs[k]) {
+			continue
+		}
+		earlier = append(earlier, h.Runs[k])
+	}
+
+	metrics := r.metrics()
+	rr := &RegressionResults{
+		Runs:      len(earlier),
+		Metrics:   metrics,
+		History:   make([][]*float64, len(metrics)),
+		PValues:   make([][]*float64, len(metrics)),
+		Regressed: make([][]bool, len(metrics)),
+	}
+	for i, m := range metrics {
+		rr.History[i] = make([]*float64, in.NumVersions)
+		rr.PValues[i] = make([]*float64, in.NumVersions)
+		rr.Regressed[i] = make([]bool, in.NumVersions)
+		// lower values are worse for max metrics
+		higher := i < len(r.Max)
+		for j := 0; j < in.NumVersions; j++ {
+			vals := []float64{}
+			for _, run := range earlier {
+				if j < len(run.Metrics) {
+					if v, ok := run.Metrics[j][m]; ok {
+						vals = append(vals, v)
+					}
+				}
+			}
+			if len(vals) < 2 {
+				log.Logger.Infof("metric %v for version %v has fewer than two values in earlier runs", m, j)
+				continue
+			}
+			mean, _ := stats.Mean(vals)
+			rr.History[i][j] = float64Pointer(mean)
+			val := in.ScalarMetricValue(j, m)
+			if val == nil {
+				log.Logger.Warnf("unable to find value for version %v and metric %s", j, m)
+				continue
+			}
+			sd, _ := stats.StandardDeviationSample(vals)
+			worse := *val - mean
+			if higher {
+				worse = -worse
+			}
+			p := regressionPValue(worse, sd, float64(len(vals)))
+			rr.PValues[i][j] = float64Pointer(p)
+			if p < 1-confidence {
+				log.Logger.Warnf("metric %v for version %v regressed; value %v, mean of earlier runs %v", m, j, *val, mean)
+				rr.Regressed[i][j] = true
+			}
+		}
+	}
+	return rr
+}
+
+// regressionPValue returns the one-sided p-value of a new value that is worse than the mean of n earlier values
+// by the given amount, where sd is the standard deviation of the earlier values
+func regressionPValue(worse float64, sd float64, n float64) float64 {
+	// standard error of the prediction of a new value
+	se := sd * math.Sqrt(1+1/n)
+	if se == 0 {
+		if worse > 0 {
+			return 0
+		}
+		return 1
+	}
+	return 1 - studentTCDF(worse/se, n-1)
+}
+
+// isRun returns true if the history run records the run of this result
+func (r *ExperimentResult) isRun(run HistoryRun) bool {
+	return run.Revision == r.Revision && run.StartTime.Equal(r.StartTime)
+}
+
+// readHistory reads the history of the experiment from its driver
+func (exp *Experiment) readHistory() (*History, error) {
+	hd, ok := exp.driver.(HistoryDriver)
+	if !ok {
+		e := errors.New("driver does not keep the history of experiment runs")
+		log.Logger.Error(e)
+		return nil, e
+	}
+	return hd.ReadHistory()
+}
+
+// regressionMetrics returns the metrics compared by assess tasks with regression checks
+// nil is returned if no assess task checks for regressions
+func (exp *Experiment) regressionMetrics() []string {
+	return exp.Spec.regressionMetrics()
+}
+
+// regressionMetrics returns the metrics compared by assess tasks with regression checks in the spec,
+// including assess tasks within parallel blocks
+func (s ExperimentSpec) regressionMetrics() []string {
+	var metrics []string
+	for _, t := range s {
+		switch tt := t.(type) {
+		case *assessTask:
+			if tt.With.Regression != nil {
+				metrics = append(metrics, tt.With.Regression.metrics()...)
+			}
+		case *parallelTask:
+			metrics = append(metrics, tt.Parallel.regressionMetrics()...)
+		}
+	}
+	return metrics
+}
+
+// recordHistory adds the scalar metrics of a completed run to the history kept by the driver
+// The history is recorded only if the experiment checks for regressions
+// A run that completes in multiple loops replaces its earlier record
+func (exp *Experiment) recordHistory(driver Driver) error {
+	metrics := exp.regressionMetrics()
+	if metrics == nil || exp.Result.Insights == nil {
+		return nil
+	}
+	hd, ok := driver.(HistoryDriver)
+	if !ok {
+		log.Logger.Warn("driver does not keep the history of experiment runs; history not recorded")
+		return nil
+	}
+	h, err := hd.ReadHistory()
+	if err != nil {
+		return err
+	}
+
+	in := exp.Result.Insights
+	run := HistoryRun{
+		Revision:  exp.Result.Revision,
+		StartTime: exp.Result.StartTime,
+		Metrics:   make([]map[string]float64, in.NumVersions),
+	}
+	for j := 0; j < in.NumVersions; j++ {
+		run.Metrics[j] = map[string]float64{}
+		// counter and gauge metrics, along with the metrics compared by regression checks
+		names := append([]string{}, metrics...)
+		for m, mm := range in.MetricsInfo {
+			if mm.Type == CounterMetricType || mm.Type == GaugeMetricType {
+				names = append(names, m)
+			}
+		}
+		for _, m := range names {
+			if v := in.ScalarMetricValue(j, m); v != nil {
+				run.Metrics[j][m] = *v
+			}
+		}
+	}
+
+	if n := len(h.Runs); n > 0 && exp.Result.isRun(h.Runs[n-1]) {
+		h.Runs[n-1] = run
+	} else {
+		h.Runs = append(h.Runs, run)
+	}
+	if len(h.Runs) > maxHistoryRuns {
+		h.Runs = h.Runs[len(h.Runs)-maxHistoryRuns:]
+	}
+	if err = hd.WriteHistory(h); err != nil {
+		return err
+	}
+	log.Logger.Infof("recorded run of revision %v in history of %v runs", run.Revision, len(h.Runs))
+	return nil
+}
+
+// NoRegression returns true if no metric of any version regressed compared with earlier runs of the experiment
+// It is also true if the experiment does not check for regressions
+func (exp *Experiment) NoRegression() bool {
+	if exp == nil || exp.Result == nil || exp.Result.Insights == nil {
+		log.Logger.Warning("experiment, or result, or insights is nil")
+		return false
+	}
+	r := exp.Result.Insights.Regressions
+	return r == nil || !r.Any()
+}
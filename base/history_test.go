@@ -0,0 +1,160 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/time"
+)
+
+// mockHistoryDriver is a mock driver that also keeps the history of experiment runs
+type mockHistoryDriver struct {
+	mockDriver
+	history *History
+}
+
+// ReadHistory reads the history of experiment runs
+func (m *mockHistoryDriver) ReadHistory() (*History, error) {
+	if m.history == nil {
+		return &History{}, nil
+	}
+	return m.history, nil
+}
+
+// WriteHistory writes the history of experiment runs
+func (m *mockHistoryDriver) WriteHistory(h *History) error {
+	m.history = h
+	return nil
+}
+
+func TestRegression(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Regression: &Regression{
+				Runs: intPointer(3),
+				Max:  []string{"a/throughput"},
+				Min:  []string{"a/latency"},
+			},
+		},
+	}
+	assert.NoError(t, task.ValidateInputs())
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(2)
+	_ = exp.Result.initInsightsWithNumVersions(1)
+	in := exp.Result.Insights
+
+	// earlier runs; the oldest run is not compared against
+	history := &History{}
+	for k, v := range [][]float64{{10, 500}, {100, 50}, {102, 52}, {98, 48}} {
+		history.Runs = append(history.Runs, HistoryRun{
+			Revision:  1,
+			StartTime: time.Unix(int64(k), 0),
+			Metrics:   []map[string]float64{{"a/latency": v[0], "a/throughput": v[1]}},
+		})
+	}
+	md := &mockHistoryDriver{history: history}
+	exp.driver = md
+
+	// latency regressed, and throughput did not
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 0, float64(130)))
+	assert.NoError(t, in.updateMetric("a/throughput", MetricMeta{Type: GaugeMetricType}, 0, float64(55)))
+	assert.NoError(t, task.Run(context.Background(), exp))
+	r := in.Regressions
+	assert.Equal(t, 3, r.Runs)
+	assert.Equal(t, []string{"a/throughput", "a/latency"}, r.Metrics)
+	assert.Equal(t, 100.0, *r.History[1][0])
+	assert.Equal(t, [][]bool{{false}, {true}}, r.Regressed)
+	assert.Less(t, *r.PValues[1][0], 0.05)
+	assert.Greater(t, *r.PValues[0][0], 0.5)
+	assert.True(t, r.Any())
+	assert.False(t, exp.NoRegression())
+
+	// values within the usual variation do not regress
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 0, float64(103)))
+	assert.NoError(t, task.Run(context.Background(), exp))
+	assert.False(t, in.Regressions.Any())
+	assert.True(t, exp.NoRegression())
+
+	// history is recorded, and the current run replaces its record
+	assert.NoError(t, exp.recordHistory(md))
+	assert.Equal(t, 5, len(md.history.Runs))
+	assert.Equal(t, 103.0, md.history.Runs[4].Metrics[0]["a/latency"])
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 0, float64(104)))
+	assert.NoError(t, exp.recordHistory(md))
+	assert.Equal(t, 5, len(md.history.Runs))
+	assert.Equal(t, 104.0, md.history.Runs[4].Metrics[0]["a/latency"])
+
+	// the current run is not compared against itself
+	assert.NoError(t, task.Run(context.Background(), exp))
+	assert.Equal(t, 100.0, *in.Regressions.History[1][0])
+
+	// history requires a driver that keeps it
+	exp.driver = &mockDriver{}
+	assert.Error(t, task.Run(context.Background(), exp))
+
+	// invalid configuration
+	task.With.Regression = &Regression{Runs: intPointer(1)}
+	assert.Error(t, task.ValidateInputs())
+}
+
+func TestRunExperimentRecordsHistory(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{&assessTask{
+			TaskMeta: TaskMeta{
+				Task: StringPointer(AssessTaskName),
+			},
+			With: assessInputs{
+				Regression: &Regression{
+					Min: []string{"a/latency"},
+				},
+			},
+		}},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(1)
+	assert.NoError(t, exp.Result.Insights.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, 0, float64(100)))
+
+	md := &mockHistoryDriver{mockDriver: mockDriver{exp}}
+	assert.NoError(t, RunExperiment(context.Background(), true, md))
+	assert.Equal(t, 1, len(md.history.Runs))
+	assert.Equal(t, 1, md.history.Runs[0].Revision)
+	assert.Equal(t, 100.0, md.history.Runs[0].Metrics[0]["a/latency"])
+	assert.Equal(t, 0, md.Experiment.Result.Insights.Regressions.Runs)
+}
+
+func TestRecordHistoryOfParallelAssess(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	exp := &Experiment{
+		Spec: []Task{&parallelTask{
+			Parallel: ExperimentSpec{&assessTask{
+				TaskMeta: TaskMeta{
+					Task: StringPointer(AssessTaskName),
+				},
+				With: assessInputs{
+					Regression: &Regression{
+						Max: []string{"a/sample/mean"},
+					},
+				},
+			}},
+		}},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(1)
+	assert.NoError(t, exp.Result.Insights.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 0, []float64{10, 20}))
+	assert.Equal(t, []string{"a/sample/mean"}, exp.regressionMetrics())
+
+	// aggregated metrics compared by assess tasks within parallel blocks are recorded
+	md := &mockHistoryDriver{mockDriver: mockDriver{exp}}
+	assert.NoError(t, exp.recordHistory(md))
+	assert.Equal(t, 1, len(md.history.Runs))
+	assert.Equal(t, 15.0, md.history.Runs[0].Metrics[0]["a/sample/mean"])
+}
@@ -0,0 +1,124 @@
+package base
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/itchyny/gojq"
+)
+
+// responseChecks are checks of responses whose status codes are not errors
+// Responses that fail a check are counted as errors, and as assertion failures
+type responseChecks struct {
+	// Jq is a jq expression evaluated on the JSON response body; the response passes if the first value is true
+	Jq *string `json:"jq,omitempty" yaml:"jq,omitempty"`
+	// Regex is a regular expression that the response body must match
+	Regex *string `json:"regex,omitempty" yaml:"regex,omitempty"`
+	// Headers maps the names of headers that the response must have to regular expressions that their values must match
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// compiledChecks are response checks with their expressions compiled
+type compiledChecks struct {
+	jq      *gojq.Code
+	regex   *regexp.Regexp
+	headers map[string]*regexp.Regexp
+}
+
+// validate checks the response checks located at the given path
+func (c *responseChecks) validate(errs *inputErrors, path string) {
+	if c.Jq != nil {
+		if _, err := compileJq(*c.Jq); err != nil {
+			errs.add(joinPath(path, "jq"), "invalid jq expression: %v", err)
+		}
+	}
+	if c.Regex != nil {
+		if _, err := regexp.Compile(*c.Regex); err != nil {
+			errs.add(joinPath(path, "regex"), "invalid regex: %v", err)
+		}
+	}
+	for name, value := range c.Headers {
+		if _, err := regexp.Compile(value); err != nil {
+			errs.add(joinPath(joinPath(path, "headers"), name), "invalid regex: %v", err)
+		}
+	}
+}
+
+// compileJq parses and compiles a jq expression
+func compileJq(expression string) (*gojq.Code, error) {
+	q, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, err
+	}
+	return gojq.Compile(q)
+}
+
+// compile compiles the expressions of the response checks
+func (c *responseChecks) compile() (*compiledChecks, error) {
+	var err error
+	cc := &compiledChecks{
+		headers: map[string]*regexp.Regexp{},
+	}
+	if c.Jq != nil {
+		if cc.jq, err = compileJq(*c.Jq); err != nil {
+			return nil, err
+		}
+	}
+	if c.Regex != nil {
+		if cc.regex, err = regexp.Compile(*c.Regex); err != nil {
+			return nil, err
+		}
+	}
+	for name, value := range c.Headers {
+		if cc.headers[name], err = regexp.Compile(value); err != nil {
+			return nil, err
+		}
+	}
+	return cc, nil
+}
+
+// check returns an error describing the first check that the response fails
+func (cc *compiledChecks) check(resp *http.Response, body []byte) error {
+	names := make([]string, 0, len(cc.headers))
+	for name := range cc.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values, ok := resp.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return fmt.Errorf("response has no header %v", name)
+		}
+		matched := false
+		for _, value := range values {
+			matched = matched || cc.headers[name].MatchString(value)
+		}
+		if !matched {
+			return fmt.Errorf("header %v of response does not match %v", name, cc.headers[name])
+		}
+	}
+	if cc.regex != nil && !cc.regex.Match(body) {
+		return fmt.Errorf("response body does not match %v", cc.regex)
+	}
+	if cc.jq != nil {
+		var jsonBody interface{}
+		if err := json.Unmarshal(body, &jsonBody); err != nil {
+			return fmt.Errorf("response body is not JSON: %v", err)
+		}
+		v, ok := cc.jq.Run(jsonBody).Next()
+		if !ok {
+			return errors.New("jq expression returned no value")
+		}
+		if err, ok := v.(error); ok {
+			return err
+		}
+		if v != true {
+			return fmt.Errorf("jq expression returned %v", v)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"fortio.org/fortio/fhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCollectHTTPChecks(t *testing.T) {
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	var n int64
+	mux.HandleFunc("/predict", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// every other response is an error with a successful status code
+		if atomic.AddInt64(&n, 1)%2 == 0 {
+			_, _ = w.Write([]byte(`{"error": "model not loaded"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"label": "cat", "score": 0.9}`))
+	})
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				NumRequests: int64Pointer(6),
+				QPS:         float32Pointer(100),
+				Connections: intPointer(1),
+				URL:         fmt.Sprintf("http://localhost:%d/predict", addr.Port),
+				Checks: &responseChecks{
+					Jq:      StringPointer(`.label == "cat"`),
+					Headers: map[string]string{"content-type": "^application/json"},
+				},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	err := ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	in := exp.Result.Insights
+	assert.Equal(t, float64(6), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountID))
+	assert.Equal(t, float64(3), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountID))
+	assert.Equal(t, 0.5, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorRateID))
+	assert.Equal(t, float64(3), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPAssertionFailuresID))
+
+	// responses are not checked by default
+	ct.With.Checks = nil
+	exp.Result.Insights = nil
+	exp.initResults(1)
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+	in = exp.Result.Insights
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountID))
+	assert.False(t, in.hasCounterOrGauge(httpMetricPrefix+"/"+builtInHTTPAssertionFailuresID))
+}
+
+func TestResponseChecks(t *testing.T) {
+	c := &responseChecks{
+		Jq:      StringPointer(".score > 0.5"),
+		Regex:   StringPointer(`"label":\s*"(cat|dog)"`),
+		Headers: map[string]string{"X-Model-Version": "^v2"},
+	}
+	cc, err := c.compile()
+	assert.NoError(t, err)
+
+	check := func(header string, body string) error {
+		resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil))}
+		if len(header) > 0 {
+			resp.Header.Set("X-Model-Version", header)
+		}
+		return cc.check(resp, []byte(body))
+	}
+	assert.NoError(t, check("v2.1", `{"label": "dog", "score": 0.7}`))
+	assert.EqualError(t, check("", `{"label": "dog", "score": 0.7}`), "response has no header X-Model-Version")
+	assert.EqualError(t, check("v1", `{"label": "dog", "score": 0.7}`), "header X-Model-Version of response does not match ^v2")
+	assert.ErrorContains(t, check("v2", `{"label": "fox", "score": 0.7}`), "response body does not match")
+	assert.EqualError(t, check("v2", `{"label": "cat", "score": 0.2}`), "jq expression returned false")
+	assert.ErrorContains(t, check("v2", `"label": "cat"`), "response body is not JSON")
+
+	errs := inputErrors{}
+	(&responseChecks{Jq: StringPointer(".["), Regex: StringPointer("("), Headers: map[string]string{"a": "["}}).validate(&errs, "with.checks")
+	assert.Len(t, errs, 3)
+	assert.Contains(t, errs.Error(), "with.checks.jq: invalid jq expression")
+	assert.Contains(t, errs.Error(), "with.checks.regex: invalid regex")
+	assert.Contains(t, errs.Error(), "with.checks.headers.a: invalid regex")
+}
@@ -0,0 +1,113 @@
+package base
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	log "github.com/iter8-tools/iter8/base/log"
+	"golang.org/x/net/http2"
+)
+
+// tlsOptions returns the Fortio TLS options of the endpoint
+func (e endpoint) tlsOptions() fhttp.TLSOptions {
+	to := fhttp.TLSOptions{}
+	if e.CACert != nil {
+		to.CACert = *e.CACert
+	}
+	if e.Cert != nil && e.Key != nil {
+		to.Cert = *e.Cert
+		to.Key = *e.Key
+	}
+	if e.InsecureSkipVerify != nil {
+		to.Insecure = *e.InsecureSkipVerify
+	}
+	return to
+}
+
+// timeout returns the timeout of requests to the endpoint
+func (e endpoint) timeout() (time.Duration, error) {
+	if e.Timeout == nil {
+		return fhttp.HTTPReqTimeOutDefaultValue, nil
+	}
+	d, err := time.ParseDuration(*e.Timeout)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to parse timeout")
+		return 0, err
+	}
+	return d, nil
+}
+
+// newHTTPClient returns a client for the requests sent by Iter8 to the endpoint, with the same TLS and connection options as Fortio
+// The client keeps its own cookies and connections, like a user of the app
+func newHTTPClient(e endpoint) (*http.Client, error) {
+	timeout, err := e.timeout()
+	if err != nil {
+		return nil, err
+	}
+	to := e.tlsOptions()
+	tlsConfig, err := to.TLSConfig()
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to configure TLS")
+		return nil, err
+	}
+	if e.Host != nil {
+		tlsConfig.ServerName = *e.Host
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		// send requests to the resolved address
+		if e.Resolve != nil {
+			addr = net.JoinHostPort(*e.Resolve, addr[strings.LastIndex(addr, ":")+1:])
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	h2 := e.HTTP2 != nil && *e.HTTP2
+	var transport http.RoundTripper = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dial,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: timeout,
+		DisableKeepAlives:   e.DisableKeepAlive != nil && *e.DisableKeepAlive,
+		ForceAttemptHTTP2:   h2,
+	}
+	if h2 {
+		transport = &h2cTransport{
+			h2c: &http2.Transport{
+				AllowHTTP: true,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					return dial(ctx, network, addr)
+				},
+			},
+			tls: transport,
+		}
+	}
+
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		Jar:       jar,
+	}, nil
+}
+
+// h2cTransport sends requests to http urls using HTTP/2 without TLS, and other requests using the tls transport
+type h2cTransport struct {
+	h2c *http2.Transport
+	tls http.RoundTripper
+}
+
+// RoundTrip sends a request
+func (t *h2cTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "http" {
+		return t.h2c.RoundTrip(req)
+	}
+	return t.tls.RoundTrip(req)
+}
@@ -0,0 +1,165 @@
+package base
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeClientCert writes a self-signed client certificate and its key, and returns a pool with the certificate
+func writeClientCert(t *testing.T, certFile string, keyFile string) *x509.CertPool {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "iter8"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
+
+func TestRunCollectHTTPTLS(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	pool := writeClientCert(t, "client.crt", "client.key")
+
+	var mu sync.Mutex
+	hosts, protos := map[string]bool{}, map[int]bool{}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		hosts[r.Host] = true
+		protos[r.ProtoMajor] = true
+		_, _ = w.Write([]byte("ok"))
+	}))
+	ts.EnableHTTP2 = true
+	ts.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	ts.StartTLS()
+	defer ts.Close()
+	err := os.WriteFile("ca.crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0600)
+	assert.NoError(t, err)
+
+	run := func(e endpoint) *Insights {
+		ct := &collectHTTPTask{
+			TaskMeta: TaskMeta{
+				Task: StringPointer(CollectHTTPTaskName),
+			},
+			With: collectHTTPInputs{endpoint: e},
+		}
+		exp := &Experiment{
+			Spec:   []Task{ct},
+			Result: &ExperimentResult{},
+		}
+		exp.initResults(1)
+		assert.NoError(t, ct.Run(context.Background(), exp))
+		return exp.Result.Insights
+	}
+	errorCount := func(in *Insights) float64 {
+		return *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountID)
+	}
+	e := endpoint{
+		NumRequests: int64Pointer(3),
+		QPS:         float32Pointer(100),
+		URL:         ts.URL,
+		CACert:      StringPointer("ca.crt"),
+		Cert:        StringPointer("client.crt"),
+		Key:         StringPointer("client.key"),
+		// the certificate of the test server is valid for example.com
+		Host:    StringPointer("example.com"),
+		Timeout: StringPointer("5s"),
+	}
+
+	// requests sent by Fortio
+	assert.Equal(t, float64(0), errorCount(run(e)))
+	assert.Equal(t, map[string]bool{"example.com": true}, hosts)
+	assert.Equal(t, map[int]bool{1: true}, protos)
+
+	e.HTTP2 = BoolPointer(true)
+	assert.Equal(t, float64(0), errorCount(run(e)))
+	assert.True(t, protos[2])
+
+	// requests sent by Iter8
+	protos = map[int]bool{}
+	e.Checks = &responseChecks{Regex: StringPointer("^ok$")}
+	assert.Equal(t, float64(0), errorCount(run(e)))
+	assert.Equal(t, map[int]bool{2: true}, protos)
+	assert.Equal(t, map[string]bool{"example.com": true}, hosts)
+
+	// the app requires a client certificate
+	e.Cert, e.Key = nil, nil
+	assert.Equal(t, float64(3), errorCount(run(e)))
+}
+
+func TestGetFortioOptionsTLS(t *testing.T) {
+	e := endpoint{
+		QPS:                float32Pointer(defaultQPS),
+		Connections:        intPointer(defaultHTTPConnections),
+		AllowInitialErrors: BoolPointer(false),
+		URL:                "https://localhost/",
+		CACert:             StringPointer("/etc/tls/ca.crt"),
+		Cert:               StringPointer("/etc/tls/tls.crt"),
+		Key:                StringPointer("/etc/tls/tls.key"),
+		InsecureSkipVerify: BoolPointer(true),
+		Resolve:            StringPointer("10.0.0.1"),
+		HTTP2:              BoolPointer(true),
+		DisableKeepAlive:   BoolPointer(true),
+		Timeout:            StringPointer("10s"),
+	}
+	fo, err := getFortioOptions(e)
+	assert.NoError(t, err)
+	assert.Equal(t, "/etc/tls/ca.crt", fo.CACert)
+	assert.Equal(t, "/etc/tls/tls.crt", fo.Cert)
+	assert.Equal(t, "/etc/tls/tls.key", fo.Key)
+	assert.True(t, fo.Insecure)
+	assert.Equal(t, "10.0.0.1", fo.Resolve)
+	assert.True(t, fo.H2)
+	assert.True(t, fo.DisableKeepAlive)
+	assert.Equal(t, 10*time.Second, fo.HTTPReqTimeOut)
+
+	// default timeout
+	e.Timeout = nil
+	fo, err = getFortioOptions(e)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, fo.HTTPReqTimeOut)
+
+	errs := inputErrors{}
+	validateEndpoint(&errs, "with", endpoint{
+		Cert:    StringPointer("tls.crt"),
+		Resolve: StringPointer("app.local"),
+		Timeout: StringPointer("-1s"),
+	})
+	assert.EqualError(t, errs, "with: cert and key must be specified together; "+
+		"with.resolve: app.local is not an IP address; with.timeout: timeout must be positive")
+}
@@ -0,0 +1,203 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// JSONLinesFormat is the format of datasets with a JSON object on each line
+	JSONLinesFormat = "jsonl"
+	// CSVFormat is the format of datasets with comma separated values, whose first row names the columns
+	CSVFormat = "csv"
+
+	// CycleOrder uses the rows of a dataset in turn, starting over after the last row
+	CycleOrder = "cycle"
+	// RandomOrder samples the rows of a dataset at random
+	RandomOrder = "random"
+)
+
+// dataset is a file of rows from which the requests of an endpoint are rendered
+//
+// The url, headers, params and payload of the endpoint are Go templates rendered with a row of the dataset for each request;
+// for example, {{ .prompt }} is the value of the prompt field or column, and {{ toJson . }} is the row as a JSON object.
+// References to outputs of earlier tasks, such as {{ .Outputs.host }}, are substituted before the task runs.
+// In a multi-step scenario, a row is used for each run, and its values are available to all the steps.
+type dataset struct {
+	// File is the path of the dataset
+	File *string `json:"file,omitempty" yaml:"file,omitempty"`
+	// URL from which the dataset is fetched. Either `file` or `url` must be specified.
+	URL *string `json:"url,omitempty" yaml:"url,omitempty"`
+	// Format of the dataset; jsonl or csv. Default value is csv if the file or URL ends with .csv, and jsonl otherwise.
+	Format *string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Order in which rows are used; cycle or random. Default value is cycle.
+	Order *string `json:"order,omitempty" yaml:"order,omitempty"`
+	// Seed of the random order, so that rows are sampled in the same order each time the task runs.
+	// Default value is based on the time at which the dataset is read.
+	Seed *int64 `json:"seed,omitempty" yaml:"seed,omitempty"`
+}
+
+// datasetRows selects the rows of a dataset for requests sent concurrently
+type datasetRows struct {
+	rows []map[string]interface{}
+	// random samples the rows if the order is random; it is nil otherwise
+	random *rand.Rand
+	// mu guards random, which is not safe for concurrent use
+	mu sync.Mutex
+	// next is the number of rows selected so far
+	next uint64
+}
+
+// validate checks the dataset located at the given path
+func (d *dataset) validate(errs *inputErrors, path string) {
+	if (d.File == nil) == (d.URL == nil) {
+		errs.add(path, "exactly one of file and url is required")
+	}
+	if d.Format != nil && *d.Format != JSONLinesFormat && *d.Format != CSVFormat {
+		errs.add(joinPath(path, "format"), "format must be jsonl or csv")
+	}
+	if d.Order != nil && *d.Order != CycleOrder && *d.Order != RandomOrder {
+		errs.add(joinPath(path, "order"), "order must be cycle or random")
+	}
+}
+
+// format returns the format of the dataset
+func (d *dataset) format() string {
+	if d.Format != nil {
+		return *d.Format
+	}
+	location := d.File
+	if d.URL != nil {
+		location = d.URL
+	}
+	if location != nil && strings.HasSuffix(strings.ToLower(*location), "."+CSVFormat) {
+		return CSVFormat
+	}
+	return JSONLinesFormat
+}
+
+// load reads the rows of the dataset
+func (d *dataset) load(ctx context.Context) (*datasetRows, error) {
+	var b []byte
+	var err error
+	if d.URL != nil {
+		b, err = fetchDataset(ctx, *d.URL)
+	} else {
+		b, err = os.ReadFile(*d.File)
+	}
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to read dataset")
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	if d.format() == CSVFormat {
+		rows, err = csvRows(b)
+	} else {
+		rows, err = jsonLinesRows(b)
+	}
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to parse dataset")
+		return nil, err
+	}
+	if len(rows) == 0 {
+		e := errors.New("dataset has no rows")
+		log.Logger.Error(e)
+		return nil, e
+	}
+	log.Logger.Infof("read dataset with %v rows", len(rows))
+	dr := &datasetRows{
+		rows: rows,
+	}
+	if d.Order != nil && *d.Order == RandomOrder {
+		seed := time.Now().UnixNano()
+		if d.Seed != nil {
+			seed = *d.Seed
+		}
+		/* #nosec */
+		dr.random = rand.New(rand.NewSource(seed))
+	}
+	return dr, nil
+}
+
+// fetchDataset fetches a dataset from a URL
+func fetchDataset(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch dataset from %v; status code %v", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// jsonLinesRows parses a dataset with a JSON object on each line
+func jsonLinesRows(b []byte) ([]map[string]interface{}, error) {
+	rows := []map[string]interface{}{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	for {
+		var row map[string]interface{}
+		err := dec.Decode(&row)
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %v is not a JSON object: %v", len(rows)+1, err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// csvRows parses a dataset of comma separated values, whose first row names the columns
+func csvRows(b []byte) ([]map[string]interface{}, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("dataset has no header row")
+	}
+	rows := make([]map[string]interface{}, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := map[string]interface{}{}
+		for k, column := range records[0] {
+			row[column] = record[k]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// row selects the row for the next request
+func (d *datasetRows) row() map[string]interface{} {
+	if d.random != nil {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.rows[d.random.Intn(len(d.rows))]
+	}
+	k := atomic.AddUint64(&d.next, 1) - 1
+	return d.rows[k%uint64(len(d.rows))]
+}
@@ -0,0 +1,216 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"fortio.org/fortio/fhttp"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRunCollectHTTPDataset(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	err := os.WriteFile("prompts.jsonl", []byte(`{"id": "a", "prompt": "hello"}
+{"id": "b", "prompt": "bonjour"}
+{"id": "c", "prompt": "hola"}
+`), 0600)
+	assert.NoError(t, err)
+
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	var mu sync.Mutex
+	received := map[string]int{}
+	mux.HandleFunc("/predict", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Query().Get("id")+" "+r.Header.Get("X-Lang")+" "+string(data)]++
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.Query().Get("q")) == 0 {
+			w.WriteHeader(400)
+		}
+	})
+	mux.HandleFunc("/queries.csv", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("q,lang\nshoes,en\nchaussures,fr\n"))
+	})
+	baseURL := fmt.Sprintf("http://localhost:%d", addr.Port)
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				NumRequests: int64Pointer(6),
+				QPS:         float32Pointer(100),
+				URL:         baseURL + "/predict",
+				Params:      map[string]string{"id": "{{ .id }}"},
+				Headers:     map[string]string{"X-Lang": `{{ .id | replace "a" "en" | replace "b" "fr" | replace "c" "es" }}`},
+				PayloadStr:  StringPointer(`{{ omit . "id" | toJson }}`),
+				Dataset:     &dataset{File: StringPointer("prompts.jsonl")},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	// rows are used in turn
+	assert.Equal(t, map[string]int{
+		`a en {"prompt":"hello"}`:   2,
+		`b fr {"prompt":"bonjour"}`: 2,
+		`c es {"prompt":"hola"}`:    2,
+	}, received)
+	in := exp.Result.Insights
+	assert.Equal(t, float64(6), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountID))
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountID))
+
+	// rows are sampled at random from a CSV dataset fetched from a URL
+	ct = &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				NumRequests: int64Pointer(4),
+				QPS:         float32Pointer(100),
+				URL:         baseURL + "/search",
+				Params:      map[string]string{"q": "{{ .q }}", "lang": "{{ .lang }}"},
+				Dataset: &dataset{
+					URL:   StringPointer(baseURL + "/queries.csv"),
+					Order: StringPointer(RandomOrder),
+				},
+			},
+		},
+	}
+	exp.Result.Insights = nil
+	exp.initResults(1)
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+	in = exp.Result.Insights
+	assert.Equal(t, float64(4), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountID))
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountID))
+}
+
+// Templates of requests rendered from a dataset can refer to the outputs of earlier tasks
+func TestRunExperimentHTTPDataset(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	err := os.WriteFile("prompts.jsonl", []byte(`{"id": "a", "prompt": "hello"}
+{"id": "b", "prompt": "bonjour"}
+`), 0600)
+	assert.NoError(t, err)
+
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	var mu sync.Mutex
+	received := map[string]int{}
+	mux.HandleFunc("/predict", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Query().Get("id")+" "+string(data)]++
+	})
+
+	e := &Experiment{}
+	err = yaml.Unmarshal([]byte(fmt.Sprintf(`
+spec:
+- run: echo predict
+  outputs:
+    path: .
+- task: http
+  with:
+    numRequests: 4
+    qps: 100
+    dataset:
+      file: prompts.jsonl
+    endpoints:
+      model:
+        url: http://localhost:%v/{{ .Outputs.path }}
+        params:
+          id: "{{ .id }}"
+        payloadStr: "{{ .prompt }}"
+`, addr.Port)), e)
+	assert.NoError(t, err)
+
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.True(t, e.NoFailure())
+	assert.Equal(t, map[string]int{"a hello": 2, "b bonjour": 2}, received)
+	in := e.Result.Insights
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, httpMetricPrefix+"-model/"+builtInHTTPErrorCountID))
+
+	// references to rows are left to the task when planning
+	plan, err := PlanExperiment(context.Background(), true, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.Empty(t, plan[1].Note)
+	model := plan[1].Task.(*collectHTTPTask).With.Endpoints["model"]
+	assert.Equal(t, fmt.Sprintf("http://localhost:%v/predict", addr.Port), model.URL)
+	assert.Equal(t, "{{.id}}", model.Params["id"])
+}
+
+func TestDatasetRows(t *testing.T) {
+	rows, err := jsonLinesRows([]byte(`{"a": 1} {"a": 2}` + "\n\n" + `{"a": "x"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"a": float64(1)}, {"a": float64(2)}, {"a": "x"}}, rows)
+
+	_, err = jsonLinesRows([]byte(`{"a": 1}` + "\n" + `[1, 2]`))
+	assert.ErrorContains(t, err, "row 2 is not a JSON object")
+
+	rows, err = csvRows([]byte("a,b\n1,x\n2,\"y, z\"\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"a": "1", "b": "x"}, {"a": "2", "b": "y, z"}}, rows)
+
+	_, err = csvRows([]byte("a,b\n1\n"))
+	assert.Error(t, err)
+
+	assert.Equal(t, CSVFormat, (&dataset{URL: StringPointer("http://host/data.CSV")}).format())
+	assert.Equal(t, JSONLinesFormat, (&dataset{File: StringPointer("data.txt")}).format())
+	assert.Equal(t, JSONLinesFormat, (&dataset{File: StringPointer("data.csv"), Format: StringPointer(JSONLinesFormat)}).format())
+
+	errs := inputErrors{}
+	(&dataset{Format: StringPointer("xml"), Order: StringPointer("sorted")}).validate(&errs, "with.dataset")
+	assert.EqualError(t, errs, "with.dataset: exactly one of file and url is required; "+
+		"with.dataset.format: format must be jsonl or csv; with.dataset.order: order must be cycle or random")
+}
+
+func TestDatasetRandomOrder(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	assert.NoError(t, os.WriteFile("data.jsonl", []byte(`{"a": 1} {"a": 2} {"a": 3} {"a": 4}`), 0600))
+	d := &dataset{File: StringPointer("data.jsonl"), Order: StringPointer(RandomOrder), Seed: new(int64)}
+
+	// rows are sampled in the same order for the same seed
+	sample := func() []interface{} {
+		dr, err := d.load(context.Background())
+		assert.NoError(t, err)
+		vals := []interface{}{}
+		for k := 0; k < 20; k++ {
+			vals = append(vals, dr.row()["a"])
+		}
+		return vals
+	}
+	assert.Equal(t, sample(), sample())
+
+	// rows are sampled concurrently
+	dr, err := d.load(context.Background())
+	assert.NoError(t, err)
+	wg := sync.WaitGroup{}
+	for k := 0; k < 4; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				assert.Contains(t, dr.rows, dr.row())
+			}
+		}()
+	}
+	wg.Wait()
+}
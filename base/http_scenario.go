@@ -0,0 +1,564 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"fortio.org/fortio/fhttp"
+	"fortio.org/fortio/periodic"
+	"fortio.org/fortio/stats"
+	"github.com/itchyny/gojq"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// scenarioStep is a request within a multi-step scenario
+//
+// The url, headers, params and payload of a step are Go templates, rendered when the request is sent.
+// Variables extracted from the responses of earlier steps are available in them; for example, {{ .token }}.
+// References to outputs of earlier tasks, such as {{ .Outputs.host }}, are substituted before the task runs.
+type scenarioStep struct {
+	// Name of the step. Metrics of the step are prefixed with http-<name>, or http-<endpoint>-<name> for an endpoint.
+	Name string `json:"name" yaml:"name"`
+	// Method is the HTTP method of the request. Default value is GET, or POST if the step has a payload.
+	Method *string `json:"method,omitempty" yaml:"method,omitempty"`
+	// URL of the request
+	URL string `json:"url" yaml:"url"`
+	// HTTP headers of the request; optional
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// HTTP query parameters of the request; optional
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+	// PayloadStr is the string data sent as the payload of the request
+	PayloadStr *string `json:"payloadStr,omitempty" yaml:"payloadStr,omitempty"`
+	// PayloadFile is the file whose data is sent as the payload of the request. If both `payloadStr` and `payloadFile` are specified, the former is ignored.
+	PayloadFile *string `json:"payloadFile,omitempty" yaml:"payloadFile,omitempty"`
+	// ContentType is the type of the payload, sent as the Content-Type HTTP header value
+	ContentType *string `json:"contentType,omitempty" yaml:"contentType,omitempty"`
+	// Extract lists the values extracted from the response into variables used by later steps
+	Extract []extraction `json:"extract,omitempty" yaml:"extract,omitempty"`
+	// Checks of the response, in addition to the checks of the endpoint; optional
+	Checks *responseChecks `json:"checks,omitempty" yaml:"checks,omitempty"`
+}
+
+// extraction extracts a value from the response of a scenario step into a variable
+// Exactly one of jq and header is specified
+type extraction struct {
+	// Var is the name of the variable
+	Var string `json:"var" yaml:"var"`
+	// Jq is a jq expression evaluated on the JSON response body; strings are extracted as is, and other values as JSON
+	Jq *string `json:"jq,omitempty" yaml:"jq,omitempty"`
+	// Header is the name of the response header whose value is extracted
+	Header *string `json:"header,omitempty" yaml:"header,omitempty"`
+}
+
+// compiledStep is a scenario step with its payload read and its templates parsed
+type compiledStep struct {
+	scenarioStep
+	method  string
+	url     *template.Template
+	headers map[string]*template.Template
+	params  map[string]*template.Template
+	payload *template.Template
+	checks  []*compiledChecks
+}
+
+// validateSteps records invalid scenario steps located at the given path
+func validateSteps(errs *inputErrors, path string, steps []scenarioStep) {
+	names := map[string]bool{}
+	for i, s := range steps {
+		sp := fmt.Sprintf("%v.steps[%v]", path, i)
+		if len(s.Name) == 0 || strings.Contains(s.Name, "/") {
+			errs.add(joinPath(sp, "name"), "name is required and cannot contain /")
+		} else if names[s.Name] {
+			errs.add(joinPath(sp, "name"), "step %v is defined more than once", s.Name)
+		}
+		names[s.Name] = true
+		if len(s.URL) == 0 {
+			errs.add(joinPath(sp, "url"), "url is required")
+		}
+		if _, err := compileStep(s, false); err != nil {
+			errs.add(sp, "%v", err)
+		}
+		for k, x := range s.Extract {
+			xp := fmt.Sprintf("%v.extract[%v]", sp, k)
+			if len(x.Var) == 0 {
+				errs.add(joinPath(xp, "var"), "var is required")
+			}
+			if (x.Jq == nil) == (x.Header == nil) {
+				errs.add(xp, "exactly one of jq and header is required")
+			}
+			if x.Jq != nil {
+				if _, err := gojq.Parse(*x.Jq); err != nil {
+					errs.add(joinPath(xp, "jq"), "invalid jq expression: %v", err)
+				}
+			}
+		}
+		if s.Checks != nil {
+			s.Checks.validate(errs, joinPath(sp, "checks"))
+		}
+	}
+}
+
+// compileStep parses the templates of a scenario step
+// The payload file is read only if readPayload is true
+func compileStep(s scenarioStep, readPayload bool) (*compiledStep, error) {
+	var err error
+	cs := &compiledStep{
+		scenarioStep: s,
+		method:       http.MethodGet,
+		headers:      map[string]*template.Template{},
+		params:       map[string]*template.Template{},
+	}
+	if cs.url, err = parseStepTemplate(s.Name+".url", s.URL); err != nil {
+		return nil, err
+	}
+	for key, value := range s.Headers {
+		if cs.headers[key], err = parseStepTemplate(s.Name+".headers."+key, value); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range s.Params {
+		if cs.params[key], err = parseStepTemplate(s.Name+".params."+key, value); err != nil {
+			return nil, err
+		}
+	}
+	payload := s.PayloadStr
+	if s.PayloadFile != nil && readPayload {
+		b, err := os.ReadFile(*s.PayloadFile)
+		if err != nil {
+			log.Logger.WithStackTrace(err.Error()).Error("unable to read payload file of step ", s.Name)
+			return nil, err
+		}
+		payload = StringPointer(string(b))
+	}
+	if payload != nil {
+		if cs.payload, err = parseStepTemplate(s.Name+".payload", *payload); err != nil {
+			return nil, err
+		}
+	}
+	if payload != nil || s.PayloadFile != nil {
+		cs.method = http.MethodPost
+	}
+	if s.Method != nil {
+		cs.method = strings.ToUpper(*s.Method)
+	}
+	if s.Checks != nil {
+		cc, err := s.Checks.compile()
+		if err != nil {
+			return nil, err
+		}
+		cs.checks = append(cs.checks, cc)
+	}
+	return cs, nil
+}
+
+// parseStepTemplate parses a template of a scenario step; templates refer to variables that must have been extracted
+func parseStepTemplate(name string, text string) (*template.Template, error) {
+	tpl, err := template.New(name).Funcs(FuncMapWithToYAML()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %v", err)
+	}
+	return tpl, nil
+}
+
+// executeStepTemplate renders a template of a scenario step with the variables extracted so far
+func executeStepTemplate(tpl *template.Template, vars map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// request renders the request of the step with the variables extracted so far
+func (cs *compiledStep) request(ctx context.Context, vars map[string]interface{}) (*http.Request, error) {
+	u, err := executeStepTemplate(cs.url, vars)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if cs.payload != nil {
+		payload, err := executeStepTemplate(cs.payload, vars)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, cs.method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(cs.params) > 0 {
+		q := req.URL.Query()
+		for key, tpl := range cs.params {
+			value, err := executeStepTemplate(tpl, vars)
+			if err != nil {
+				return nil, err
+			}
+			q.Add(key, value)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+	if cs.ContentType != nil {
+		req.Header.Set("Content-Type", *cs.ContentType)
+	}
+	for key, tpl := range cs.headers {
+		value, err := executeStepTemplate(tpl, vars)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(key, "host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// extract sets the variables extracted from the response of the step
+func (cs *compiledStep) extract(resp *http.Response, body []byte, vars map[string]interface{}) error {
+	var jsonBody interface{}
+	parsed := false
+	for _, x := range cs.Extract {
+		if x.Header != nil {
+			value := resp.Header.Get(*x.Header)
+			if len(value) == 0 {
+				return fmt.Errorf("response has no header %v", *x.Header)
+			}
+			vars[x.Var] = value
+			continue
+		}
+		if !parsed {
+			if err := json.Unmarshal(body, &jsonBody); err != nil {
+				return fmt.Errorf("response body is not JSON: %v", err)
+			}
+			parsed = true
+		}
+		value, err := evaluateOutput(*x.Jq, jsonBody)
+		if err != nil {
+			return err
+		}
+		vars[x.Var] = value
+	}
+	return nil
+}
+
+// scenarioRecorder records the latencies, status codes and assertion failures of steps and of entire runs of a scenario, keyed by metric prefix
+type scenarioRecorder struct {
+	mu         sync.Mutex
+	histograms map[string]*stats.Histogram
+	codes      map[string]map[int]int64
+	failures   map[string]int64
+}
+
+// record records a request, or a run, that took the given duration and ended with the given status code
+// failed is true if the response failed a response check
+func (r *scenarioRecorder) record(prefix string, d time.Duration, code int, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.histograms[prefix]; !ok {
+		r.histograms[prefix] = stats.NewHistogram(0, periodic.DefaultRunnerOptions.Resolution)
+		r.codes[prefix] = map[int]int64{}
+	}
+	r.histograms[prefix].Record(d.Seconds())
+	r.codes[prefix][code]++
+	if failed {
+		r.failures[prefix]++
+	}
+}
+
+// scenario is the compiled scenario of an endpoint
+type scenario struct {
+	steps []*compiledStep
+	// perStep is true if the results of each step are recorded along with those of entire runs
+	perStep bool
+	// rows of the dataset of the endpoint; nil if the endpoint has no dataset
+	rows *datasetRows
+	// checked is true if responses are checked
+	checked bool
+	// prefix of the metrics of entire runs
+	prefix string
+	rec    *scenarioRecorder
+}
+
+// ownsTemplate returns true if the input at the given path is a template rendered by the task,
+// such as the inputs of scenario steps, and the request inputs of an endpoint with a dataset
+func (t *collectHTTPTask) ownsTemplate(path []string) bool {
+	// endpoints inherit the dataset of the task
+	hasDataset := t.With.Dataset != nil
+	if len(path) > 2 && path[0] == "endpoints" {
+		hasDataset = hasDataset || t.With.Endpoints[path[1]].Dataset != nil
+		path = path[2:]
+	}
+	if len(path) == 0 {
+		return false
+	}
+	switch path[0] {
+	case "steps":
+		return true
+	case "url", "headers", "params", "payloadStr":
+		return hasDataset
+	default:
+		return false
+	}
+}
+
+// requestStep returns the request of an endpoint without steps as a single step
+func (e endpoint) requestStep() scenarioStep {
+	return scenarioStep{
+		URL:         e.URL,
+		Headers:     e.Headers,
+		Params:      e.Params,
+		PayloadStr:  e.PayloadStr,
+		PayloadFile: e.PayloadFile,
+		ContentType: e.ContentType,
+		Checks:      e.Checks,
+	}
+}
+
+// runScenario runs the scenario of the endpoint
+// Results of entire runs are keyed by the given prefix, and results of each step by prefix-<step name>
+// An endpoint without steps sends a single request in each run, and only the results of runs are keyed
+//
+// NumRequests and Duration bound the number of runs, and QPS is the rate at which runs start.
+// If the endpoint has a load profile, runs start following its stages instead, and their results are also keyed by prefix-<stage name>.
+// Connections is the number of concurrent runs; each connection keeps its own cookies, like a user of the app.
+// A run ends at the first step whose response is an error, fails a check, or whose values cannot be extracted.
+func (t *collectHTTPTask) runScenario(ctx context.Context, e endpoint, prefix string) (map[string]*httpResult, error) {
+	sc := &scenario{
+		perStep: len(e.Steps) > 0,
+		prefix:  prefix,
+		rec: &scenarioRecorder{
+			histograms: map[string]*stats.Histogram{},
+			codes:      map[string]map[int]int64{},
+			failures:   map[string]int64{},
+		},
+	}
+	steps := e.Steps
+	if !sc.perStep {
+		steps = []scenarioStep{e.requestStep()}
+	}
+	for _, s := range steps {
+		cs, err := compileStep(s, true)
+		if err != nil {
+			log.Logger.Error(fmt.Sprintf("could not compile step \"%s\"", s.Name))
+			return nil, err
+		}
+		// the checks of the endpoint apply to all steps
+		if sc.perStep && e.Checks != nil {
+			cc, err := e.Checks.compile()
+			if err != nil {
+				log.Logger.Error("could not compile response checks")
+				return nil, err
+			}
+			cs.checks = append(cs.checks, cc)
+		}
+		// the host of the endpoint applies to steps without a host header
+		if e.Host != nil && !hasHeader(s.Headers, "host") {
+			if cs.headers["Host"], err = parseStepTemplate(s.Name+".host", *e.Host); err != nil {
+				return nil, err
+			}
+		}
+		sc.checked = sc.checked || len(cs.checks) > 0
+		sc.steps = append(sc.steps, cs)
+	}
+	if e.Dataset != nil {
+		var err error
+		if sc.rows, err = e.Dataset.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// each connection has its own client
+	clients := make([]*http.Client, *e.Connections)
+	for c := range clients {
+		var err error
+		if clients[c], err = newHTTPClient(e); err != nil {
+			return nil, err
+		}
+	}
+
+	// runs are bounded by duration only if the number of runs is not specified
+	var deadline <-chan time.Time
+	if e.NumRequests == nil && e.Duration != nil {
+		d, err := time.ParseDuration(*e.Duration)
+		if err != nil {
+			log.Logger.WithStackTrace(err.Error()).Error("unable to parse duration")
+			return nil, err
+		}
+		deadline = time.After(d)
+	}
+
+	// start runs following the load profile, or at the given rate until enough runs have started, or time is up
+	// the metric prefix of the stage of each run is sent on runs
+	runs := make(chan string)
+	if len(e.Stages) > 0 {
+		go sc.paceStages(ctx, e.Stages, runs)
+	} else {
+		go sc.pace(ctx, e, deadline, runs)
+	}
+
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *http.Client) {
+			defer wg.Done()
+			for stage := range runs {
+				t.runScenarioOnce(ctx, client, sc, stage)
+			}
+		}(client)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		log.Logger.Warn("aborted HTTP scenario")
+		return nil, ctx.Err()
+	}
+
+	results := map[string]*httpResult{}
+	for p, h := range sc.rec.histograms {
+		results[p] = &httpResult{
+			HTTPRunnerResults: &fhttp.HTTPRunnerResults{
+				RunnerResults: periodic.RunnerResults{
+					DurationHistogram: h.Export().CalcPercentiles(e.Percentiles),
+				},
+				RetCodes: sc.rec.codes[p],
+			},
+			partial: p != sc.prefix,
+		}
+		if sc.checked {
+			results[p].assertionFailures = int64Pointer(sc.rec.failures[p])
+		}
+	}
+	if len(results) == 0 {
+		err := errors.New("no runs of the HTTP scenario")
+		log.Logger.Error(err)
+		return nil, err
+	}
+	return results, nil
+}
+
+// pace starts runs at the QPS of the endpoint until enough runs have started, or the deadline is reached
+func (sc *scenario) pace(ctx context.Context, e endpoint, deadline <-chan time.Time, runs chan<- string) {
+	defer close(runs)
+	interval := time.Duration(float64(time.Second) / float64(*e.QPS))
+	if interval <= 0 {
+		interval = time.Nanosecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for k := int64(0); e.NumRequests == nil || k < *e.NumRequests; k++ {
+		select {
+		case runs <- "":
+		case <-deadline:
+			return
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runScenarioOnce runs the steps of a scenario in order, and records the results of the run, and of each step if required
+// The result of the run is also recorded under the prefix of its stage, unless the prefix is empty
+// The variables of the run start with the values of a row of the dataset, if any
+// The status code of the run is that of its last response; it is -1 if values could not be extracted from the response
+// A run whose last response failed a check is an assertion failure
+func (t *collectHTTPTask) runScenarioOnce(ctx context.Context, client *http.Client, sc *scenario, stage string) {
+	vars := map[string]interface{}{}
+	if sc.rows != nil {
+		for key, value := range sc.rows.row() {
+			vars[key] = value
+		}
+	}
+	start := time.Now()
+	code, failed := -1, false
+	for _, s := range sc.steps {
+		prefix := ""
+		if sc.perStep {
+			prefix = sc.prefix + "-" + s.Name
+		}
+		code, failed = t.runStep(ctx, client, s, vars, prefix, sc.rec)
+		if t.errorCode(code) || failed {
+			break
+		}
+	}
+	sc.rec.record(sc.prefix, time.Since(start), code, failed)
+	if len(stage) > 0 {
+		sc.rec.record(stage, time.Since(start), code, failed)
+	}
+}
+
+// runStep sends the request of a step, checks its response, and extracts values from the response
+// The result of the request is recorded under the given prefix, unless the prefix is empty
+// It returns the status code of the response, or -1 if the request could not be sent or values could not be extracted,
+// along with whether the response failed a check
+func (t *collectHTTPTask) runStep(ctx context.Context, client *http.Client, s *compiledStep, vars map[string]interface{}, prefix string, rec *scenarioRecorder) (int, bool) {
+	req, err := s.request(ctx, vars)
+	if err != nil {
+		log.Logger.Warnf("unable to render request of step %v: %v", s.Name, err)
+		return -1, false
+	}
+	record := func(d time.Duration, code int, failed bool) {
+		if len(prefix) > 0 {
+			rec.record(prefix, d, code, failed)
+		}
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Logger.Debugf("request of step %v failed: %v", s.Name, err)
+		record(time.Since(start), -1, false)
+		return -1, false
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	d := time.Since(start)
+	if err != nil {
+		log.Logger.Debugf("unable to read response of step %v: %v", s.Name, err)
+		record(d, -1, false)
+		return -1, false
+	}
+	if t.errorCode(resp.StatusCode) {
+		record(d, resp.StatusCode, false)
+		return resp.StatusCode, false
+	}
+	for _, cc := range s.checks {
+		if err = cc.check(resp, body); err != nil {
+			log.Logger.Debugf("response of step %v failed check: %v", s.Name, err)
+			record(d, resp.StatusCode, true)
+			return resp.StatusCode, true
+		}
+	}
+	record(d, resp.StatusCode, false)
+	if err = s.extract(resp, body, vars); err != nil {
+		log.Logger.Warnf("unable to extract values from response of step %v: %v", s.Name, err)
+		return -1, false
+	}
+	return resp.StatusCode, false
+}
+
+// hasHeader returns true if the headers include the given header
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"fortio.org/fortio/fhttp"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRunCollectHTTPScenario(t *testing.T) {
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"user": "alice"}`, string(data))
+		w.Header().Set("X-Session", "s1")
+		_, _ = w.Write([]byte(`{"token": "abc", "user": {"id": 7}}`))
+	})
+	mux.HandleFunc("/users/7/cart", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" || r.Header.Get("X-Session") != "s1" {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(200)
+	})
+	baseURL := fmt.Sprintf("http://localhost:%d", addr.Port)
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				NumRequests: int64Pointer(6),
+				QPS:         float32Pointer(100),
+				Connections: intPointer(2),
+				Steps: []scenarioStep{{
+					Name:        "login",
+					URL:         baseURL + "/login",
+					PayloadStr:  StringPointer(`{"user": "alice"}`),
+					ContentType: StringPointer("application/json"),
+					Extract: []extraction{
+						{Var: "token", Jq: StringPointer(".token")},
+						{Var: "id", Jq: StringPointer(".user.id")},
+						{Var: "session", Header: StringPointer("X-Session")},
+					},
+				}, {
+					Name: "cart",
+					URL:  baseURL + "/users/{{ .id }}/cart",
+					Headers: map[string]string{
+						"Authorization": "Bearer {{ .token }}",
+						"X-Session":     "{{ .session }}",
+					},
+				}},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	err := ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	// metrics of entire runs and of each step
+	in := exp.Result.Insights
+	for _, prefix := range []string{httpMetricPrefix, httpMetricPrefix + "-login", httpMetricPrefix + "-cart"} {
+		assert.Equal(t, float64(6), *in.ScalarMetricValue(0, prefix+"/"+builtInHTTPRequestCountID), prefix)
+		assert.Equal(t, float64(0), *in.ScalarMetricValue(0, prefix+"/"+builtInHTTPErrorCountID), prefix)
+	}
+	// only the latencies of runs are recorded in the latency histogram
+	assert.InDelta(t, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountID), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPLatencyHistID+"/count"), 1e-6)
+	assert.Nil(t, in.ScalarMetricValue(0, httpMetricPrefix+"-login/"+builtInHTTPLatencyHistID+"/count"))
+	// a run takes at least as long as its steps
+	assert.GreaterOrEqual(t,
+		*in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPLatencyMaxID),
+		*in.ScalarMetricValue(0, httpMetricPrefix+"-cart/"+builtInHTTPLatencyMaxID))
+
+	// runs end when values cannot be extracted
+	ct.With.Steps[0].Extract[0].Jq = StringPointer(".missing | error")
+	ct.With.Endpoints = map[string]endpoint{endpoint1: {}}
+	exp.Result.Insights = nil
+	exp.initResults(1)
+	err = ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+	in = exp.Result.Insights
+	prefix := httpMetricPrefix + "-" + endpoint1
+	assert.Equal(t, float64(6), *in.ScalarMetricValue(0, prefix+"/"+builtInHTTPErrorCountID))
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, prefix+"-login/"+builtInHTTPErrorCountID))
+	assert.Nil(t, in.ScalarMetricValue(0, prefix+"-cart/"+builtInHTTPRequestCountID))
+}
+
+// Templates of steps are rendered by the task, and can refer to the outputs of earlier tasks
+func TestRunExperimentHTTPScenario(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"user": "alice"}`, string(data))
+		_, _ = w.Write([]byte(`{"token": "abc"}`))
+	})
+	mux.HandleFunc("/users/alice/cart", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(401)
+		}
+	})
+
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+spec:
+- run: echo alice
+  outputs:
+    user: .
+- task: http
+  with:
+    numRequests: 4
+    qps: 100
+    steps:
+    - name: login
+      url: http://localhost:%[1]v/login
+      payloadStr: '{"user": "{{ .Outputs.user }}"}'
+      extract:
+      - var: token
+        jq: .token
+    - name: cart
+      url: http://localhost:%[1]v/users/{{ .Outputs.user }}/cart
+      headers:
+        Authorization: Bearer {{ .token }}
+`, addr.Port)), e)
+	assert.NoError(t, err)
+
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.True(t, e.NoFailure())
+	in := e.Result.Insights
+	for _, prefix := range []string{httpMetricPrefix, httpMetricPrefix + "-cart"} {
+		assert.Equal(t, float64(4), *in.ScalarMetricValue(0, prefix+"/"+builtInHTTPRequestCountID), prefix)
+		assert.Equal(t, float64(0), *in.ScalarMetricValue(0, prefix+"/"+builtInHTTPErrorCountID), prefix)
+	}
+	// the templates are left as they are
+	assert.Equal(t, "Bearer {{ .token }}", e.Spec[1].(*collectHTTPTask).With.Steps[1].Headers["Authorization"])
+}
+
+func TestValidateHTTPScenario(t *testing.T) {
+	ct := &collectHTTPTask{
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				Steps: []scenarioStep{{
+					Name: "login",
+					URL:  "http://localhost/login",
+					Extract: []extraction{
+						{Var: "token", Jq: StringPointer(".token"), Header: StringPointer("X-Token")},
+						{Jq: StringPointer(".[")},
+					},
+				}, {
+					Name:    "login",
+					URL:     "http://localhost/{{ .token ",
+					Headers: map[string]string{"Authorization": "Bearer {{ .token }}"},
+				}},
+			},
+		},
+	}
+	err := ct.ValidateInputs()
+	assert.Error(t, err)
+	for _, path := range []string{
+		"with.steps[0].extract[0]",
+		"with.steps[0].extract[1].var",
+		"with.steps[0].extract[1].jq",
+		"with.steps[1].name",
+		"with.steps[1]",
+	} {
+		assert.Contains(t, err.Error(), path+":")
+	}
+	assert.NotContains(t, err.Error(), "with.url")
+}
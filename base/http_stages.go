@@ -0,0 +1,145 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// StageShape is the shape of the load during a stage of a load profile
+type StageShape string
+
+const (
+	// SteadyShape keeps the rate of requests at the QPS of the stage
+	SteadyShape StageShape = "steady"
+	// RampShape changes the rate of requests linearly from the rate at the end of the previous stage to the QPS of the stage
+	// The first ramp starts from zero
+	RampShape StageShape = "ramp"
+	// SpikeShape raises the rate of requests to the QPS of the stage; the next stage starts from the rate before the spike
+	SpikeShape StageShape = "spike"
+)
+
+// loadStage is a stage of a load profile
+type loadStage struct {
+	// Name of the stage. Metrics of the stage are prefixed with http-<name>, or http-<endpoint>-<name> for an endpoint.
+	// The latency histogram of the stage is not recorded, since its runs are part of the latency histogram of the entire load test.
+	Name string `json:"name" yaml:"name"`
+	// Duration of the stage. Specified in the Go duration string format (example, 30s).
+	Duration string `json:"duration" yaml:"duration"`
+	// QPS is the number of requests per second at the end of the stage
+	QPS float32 `json:"qps" yaml:"qps"`
+	// Shape of the load; steady, ramp, or spike. Default value is steady.
+	Shape StageShape `json:"shape,omitempty" yaml:"shape,omitempty"`
+}
+
+// validateStages records invalid stages located at the given path
+// Stage names cannot be the same as step names, since both are used as metric prefixes
+func validateStages(errs *inputErrors, path string, stages []loadStage, steps []scenarioStep) {
+	names := map[string]bool{}
+	for _, s := range steps {
+		names[s.Name] = true
+	}
+	for i, s := range stages {
+		sp := fmt.Sprintf("%v.stages[%v]", path, i)
+		if len(s.Name) == 0 || strings.Contains(s.Name, "/") {
+			errs.add(joinPath(sp, "name"), "name is required and cannot contain /")
+		} else if names[s.Name] {
+			errs.add(joinPath(sp, "name"), "stage or step %v is defined more than once", s.Name)
+		}
+		names[s.Name] = true
+		if d, err := time.ParseDuration(s.Duration); err != nil {
+			errs.add(joinPath(sp, "duration"), "invalid duration %v", s.Duration)
+		} else if d <= 0 {
+			errs.add(joinPath(sp, "duration"), "duration must be positive")
+		}
+		if s.QPS < 0 {
+			errs.add(joinPath(sp, "qps"), "qps cannot be negative")
+		}
+		if len(s.Shape) > 0 && s.Shape != SteadyShape && s.Shape != RampShape && s.Shape != SpikeShape {
+			errs.add(joinPath(sp, "shape"), "shape must be steady, ramp, or spike")
+		}
+	}
+}
+
+// nextStart returns the time, in seconds since the start of a stage, at which the run after the one started at t is due
+// The rate changes linearly from r0 to r1 over the stage, which lasts d seconds; +Inf is returned if no more runs are due
+func nextStart(t float64, r0 float64, r1 float64, d float64) float64 {
+	// the number of runs due between t and t+dt is b*dt + a*dt*dt
+	a := (r1 - r0) / (2 * d)
+	b := r0 + (r1-r0)*t/d
+	if a == 0 {
+		if b <= 0 {
+			return math.Inf(1)
+		}
+		return t + 1/b
+	}
+	disc := b*b + 4*a
+	if disc < 0 {
+		return math.Inf(1)
+	}
+	return t + (-b+math.Sqrt(disc))/(2*a)
+}
+
+// stageSchedule returns the times, in seconds since the start of the stage, at which runs are due in each stage of the load profile
+// The schedule does not depend on how long runs take, so the number of runs in each stage is known in advance
+func stageSchedule(stages []loadStage) [][]float64 {
+	schedule := make([][]float64, len(stages))
+	// rate at the end of the previous stage that is not a spike
+	level := 0.0
+	for i, st := range stages {
+		d, _ := time.ParseDuration(st.Duration)
+		r0, r1 := float64(st.QPS), float64(st.QPS)
+		if st.Shape == RampShape {
+			r0 = level
+		}
+		if st.Shape != SpikeShape {
+			level = r1
+		}
+		log.Logger.Debugf("stage %v: %v qps to %v qps in %v", st.Name, r0, r1, d)
+		for next := nextStart(0, r0, r1, d.Seconds()); next < d.Seconds(); next = nextStart(next, r0, r1, d.Seconds()) {
+			schedule[i] = append(schedule[i], next)
+		}
+	}
+	return schedule
+}
+
+// paceStages starts runs following the stages of the load profile, and closes runs when the last stage ends
+// The metric prefix of the stage of each run is sent on runs
+func (sc *scenario) paceStages(ctx context.Context, stages []loadStage, runs chan<- string) {
+	defer close(runs)
+	schedule := stageSchedule(stages)
+	for i, st := range stages {
+		d, _ := time.ParseDuration(st.Duration)
+		prefix := sc.prefix + "-" + st.Name
+		start := time.Now()
+		for _, next := range schedule[i] {
+			if !sleepUntil(ctx, start.Add(time.Duration(next*float64(time.Second)))) {
+				return
+			}
+			select {
+			case runs <- prefix:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if !sleepUntil(ctx, start.Add(d)) {
+			return
+		}
+	}
+}
+
+// sleepUntil waits until the given time; false is returned if ctx is done first
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"fortio.org/fortio/fhttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextStart(t *testing.T) {
+	// steady
+	assert.InDelta(t, 1.1, nextStart(1, 10, 10, 5), 1e-9)
+	assert.True(t, math.IsInf(nextStart(0, 0, 0, 5), 1))
+	// ramp up from zero; 0.5*t*t runs are due by time t
+	assert.InDelta(t, math.Sqrt(2), nextStart(0, 0, 10, 10), 1e-9)
+	assert.InDelta(t, 2, nextStart(math.Sqrt(2), 0, 10, 10), 1e-9)
+	// ramp down to zero; 5 runs are due in total
+	next, n := 0.0, 0
+	for next = nextStart(0, 10, 0, 1); next < 1; next = nextStart(next, 10, 0, 1) {
+		n++
+	}
+	assert.Equal(t, 5, n)
+}
+
+func TestStageSchedule(t *testing.T) {
+	schedule := stageSchedule([]loadStage{
+		{Name: "rampup", Duration: "250ms", QPS: 50, Shape: RampShape},
+		{Name: "steady", Duration: "250ms", QPS: 50},
+		{Name: "spike", Duration: "110ms", QPS: 150, Shape: SpikeShape},
+		{Name: "rampdown", Duration: "250ms", QPS: 0, Shape: RampShape},
+	})
+	counts := []int{}
+	for _, starts := range schedule {
+		counts = append(counts, len(starts))
+	}
+	assert.Equal(t, []int{6, 12, 16, 6}, counts)
+	// the steady stage starts a run every 20ms
+	assert.InDelta(t, 0.02, schedule[1][0], 1e-9)
+	assert.InDelta(t, 0.24, schedule[1][11], 1e-9)
+}
+
+func TestRunCollectHTTPStages(t *testing.T) {
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	mux.HandleFunc("/"+foo, func(w http.ResponseWriter, r *http.Request) {})
+
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(CollectHTTPTaskName),
+		},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				Connections: intPointer(8),
+				URL:         fmt.Sprintf("http://localhost:%d/%s", addr.Port, foo),
+				Stages: []loadStage{
+					{Name: "rampup", Duration: "250ms", QPS: 50, Shape: RampShape},
+					{Name: "steady", Duration: "250ms", QPS: 50},
+					{Name: "spike", Duration: "110ms", QPS: 150, Shape: SpikeShape},
+					{Name: "rampdown", Duration: "250ms", QPS: 0, Shape: RampShape},
+				},
+			},
+		},
+	}
+	exp := &Experiment{
+		Spec:   []Task{ct},
+		Result: &ExperimentResult{},
+	}
+	exp.initResults(1)
+	err := ct.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	// metrics of each stage, and of the entire load test
+	// every run is counted in its stage, no matter how long runs are delayed
+	in := exp.Result.Insights
+	total := 0.0
+	for _, stage := range []string{"rampup", "steady", "spike", "rampdown"} {
+		m := httpMetricPrefix + "-" + stage + "/" + builtInHTTPRequestCountID
+		v := in.ScalarMetricValue(0, m)
+		if assert.NotNil(t, v, m) {
+			assert.Greater(t, *v, float64(0), m)
+			total += *v
+		}
+	}
+	requests := *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPRequestCountID)
+	assert.Equal(t, float64(40), requests)
+	assert.Equal(t, requests, total)
+	assert.Equal(t, float64(0), *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPErrorCountID))
+
+	// runs of stages are counted once in the latency histogram
+	assert.InDelta(t, requests, *in.ScalarMetricValue(0, httpMetricPrefix+"/"+builtInHTTPLatencyHistID+"/count"), 1e-6)
+	assert.Nil(t, in.ScalarMetricValue(0, httpMetricPrefix+"-steady/"+builtInHTTPLatencyHistID+"/count"))
+
+	// stage names are metric prefixes
+	ct.With.Steps = []scenarioStep{{Name: "steady", URL: ct.With.URL}}
+	ct.With.Stages[1].Duration = "0s"
+	ct.With.Stages[2].Shape = "burst"
+	err = ct.ValidateInputs()
+	assert.ErrorContains(t, err, "with.stages[1].name: stage or step steady is defined more than once")
+	assert.ErrorContains(t, err, "with.stages[1].duration: duration must be positive")
+	assert.ErrorContains(t, err, "with.stages[2].shape: shape must be steady, ramp, or spike")
+}
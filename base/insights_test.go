@@ -5,6 +5,7 @@ import (
 
 	"github.com/iter8-tools/iter8/base/summarymetrics"
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
 )
 
 func TestTrackVersionStr(t *testing.T) {
@@ -123,3 +124,94 @@ func TestAggregateMetric(t *testing.T) {
 	assert.Equal(t, float64(11), *in.aggregateMetric(0, "prefix/summary/mean"))
 	assert.Equal(t, float64(11), *in.aggregateMetric(0, "prefix/sample/mean"))
 }
+
+func TestLoopMetricValues(t *testing.T) {
+	r := &ExperimentResult{}
+	perLoopCounter := MetricMeta{Type: CounterMetricType, PerLoop: true}
+	perLoopGauge := MetricMeta{Type: GaugeMetricType, PerLoop: true}
+	counter := MetricMeta{Type: CounterMetricType}
+
+	// metrics observed in three loops; the gauge is missing in loop 2
+	for loop, v := range []float64{100, 50, 150} {
+		r.NumLoops = loop + 1
+		assert.NoError(t, r.initInsightsWithNumVersions(1))
+		in := r.Insights
+		assert.NoError(t, in.updateMetric("a/request-count", perLoopCounter, 0, v))
+		assert.NoError(t, in.updateMetric("a/total", counter, 0, float64(loop+1)*100))
+		if loop != 1 {
+			assert.NoError(t, in.updateMetric("a/error-rate", perLoopGauge, 0, v/1000))
+		}
+	}
+	in := r.Insights
+	assert.Equal(t, []int{1, 2, 3}, in.NonHistMetricLoops[0]["a/request-count"])
+
+	// per-loop counters are cumulative, and per-loop gauges describe the latest loop
+	assert.Equal(t, float64(300), *in.ScalarMetricValue(0, "a/request-count"))
+	assert.Equal(t, float64(150), *in.latestMetricValue(0, "a/request-count"))
+	assert.Equal(t, float64(300), *in.ScalarMetricValue(0, "a/total"))
+	assert.Equal(t, 0.15, *in.ScalarMetricValue(0, "a/error-rate"))
+
+	// values at the end of a loop
+	assert.Equal(t, float64(150), *in.LoopMetricValue(0, "a/request-count", 2))
+	assert.Equal(t, float64(200), *in.LoopMetricValue(0, "a/total", 2))
+	assert.Equal(t, 0.1, *in.LoopMetricValue(0, "a/error-rate", 1))
+	assert.Nil(t, in.LoopMetricValue(0, "a/error-rate", 2))
+	assert.Nil(t, in.LoopMetricValue(0, "a/request-count", 0))
+	assert.Nil(t, in.LoopMetricValue(0, "a/unknown", 1))
+
+	// per-loop rates are computed from the counts in all loops, and describe a single loop at the end of that loop
+	for loop, v := range []float64{10, 0, 20} {
+		r.NumLoops = loop + 1
+		assert.NoError(t, in.updateMetric("a/error-count", perLoopCounter, 0, v))
+	}
+	assert.Equal(t, 0.1, *in.ScalarMetricValue(0, "a/error-rate"))
+	assert.Equal(t, 0.1, *in.LoopMetricValue(0, "a/error-rate", 1))
+	assert.Equal(t, 0.15, *in.LoopMetricValue(0, "a/error-rate", 3))
+
+	// values observed before loops were recorded
+	in.NonHistMetricLoops = nil
+	assert.Nil(t, in.LoopMetricValue(0, "a/total", 3))
+	assert.NoError(t, in.updateMetric("a/total", counter, 0, float64(400)))
+	assert.Equal(t, []int{0, 0, 0, 3}, in.NonHistMetricLoops[0]["a/total"])
+	assert.Equal(t, float64(400), *in.LoopMetricValue(0, "a/total", 3))
+}
+
+func TestResumedCounterValues(t *testing.T) {
+	// result stored before per-loop semantics were defined; counter values are cumulative snapshots
+	r := &ExperimentResult{}
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+numLoops: 3
+insights:
+  numVersions: 1
+  metricsInfo:
+    a/request-count:
+      type: Counter
+  nonHistMetricValues:
+  - a/request-count: [100, 250, 400]
+  nonHistMetricLoops:
+  - a/request-count: [1, 2, 3]
+  histMetricValues:
+  - {}
+  summaryMetricValues:
+  - {}
+`), r))
+	in := r.Insights
+	assert.Equal(t, float64(400), *in.ScalarMetricValue(0, "a/request-count"))
+
+	// values of a failed attempt are discarded after the stored values were converted
+	mark := r.markInsights()
+	r.NumLoops = 4
+	assert.NoError(t, r.initInsightsWithNumVersions(1))
+	assert.NoError(t, in.updateMetric("a/request-count", MetricMeta{Type: CounterMetricType, PerLoop: true}, 0, float64(50)))
+	assert.Equal(t, []float64{400, 50}, in.NonHistMetricValues[0]["a/request-count"])
+	assert.Equal(t, []int{3, 4}, in.NonHistMetricLoops[0]["a/request-count"])
+	assert.Equal(t, float64(450), *in.ScalarMetricValue(0, "a/request-count"))
+	r.rollbackInsights(mark)
+	assert.Equal(t, []float64{100, 250, 400}, in.NonHistMetricValues[0]["a/request-count"])
+	assert.False(t, in.MetricsInfo["a/request-count"].PerLoop)
+
+	// the resumed loop counts its requests on top of the stored snapshot
+	assert.NoError(t, in.updateMetric("a/request-count", MetricMeta{Type: CounterMetricType, PerLoop: true}, 0, float64(50)))
+	assert.Equal(t, float64(450), *in.ScalarMetricValue(0, "a/request-count"))
+	assert.Equal(t, float64(450), *in.LoopMetricValue(0, "a/request-count", 4))
+}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -48,9 +49,9 @@ func TestMockQuickStartWithSLOs(t *testing.T) {
 
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
-	err := exp.Spec[0].run(exp)
+	err := exp.Spec[0].Run(context.Background(), exp)
 	assert.NoError(t, err)
-	err = exp.Spec[1].run(exp)
+	err = exp.Spec[1].Run(context.Background(), exp)
 	assert.NoError(t, err)
 	// assert SLOs are satisfied
 	for _, v := range exp.Result.Insights.SLOsSatisfied.Upper {
@@ -102,9 +103,9 @@ func TestMockQuickStartWithSLOsAndPercentiles(t *testing.T) {
 
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
-	err := exp.Spec[0].run(exp)
+	err := exp.Spec[0].Run(context.Background(), exp)
 	assert.NoError(t, err)
-	err = exp.Spec[1].run(exp)
+	err = exp.Spec[1].Run(context.Background(), exp)
 	assert.NoError(t, err)
 	// assert SLOs are satisfied
 	for _, v := range exp.Result.Insights.SLOsSatisfied.Upper {
@@ -2,6 +2,7 @@ package base
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -66,6 +67,9 @@ type Report struct {
 	// NumLoops is the current loop of the experiment
 	NumLoops int `json:"numLoops" yaml:"numLoops"`
 
+	// TaskResults are the execution records of the tasks that have run so far
+	TaskResults []TaskResult `json:"taskResults,omitempty" yaml:"taskResults,omitempty"`
+
 	// Experiment is the experiment struct
 	Experiment *Experiment `json:"experiment" yaml:"experiment"`
 }
@@ -80,6 +84,7 @@ func getReport(exp *Experiment) map[string]Report {
 			NumTasks:          len(exp.Spec),
 			NumCompletedTasks: exp.Result.NumCompletedTasks,
 			NumLoops:          exp.Result.NumLoops,
+			TaskResults:       exp.Result.TaskResults,
 			Experiment:        exp,
 		},
 	}
@@ -87,9 +92,9 @@ func getReport(exp *Experiment) map[string]Report {
 
 // getPayload fetches the payload template from the PayloadTemplateURL and
 // executes it with values from getReport()
-func (t *notifyTask) getPayload(exp *Experiment) (string, error) {
+func (t *notifyTask) getPayload(ctx context.Context, exp *Experiment) (string, error) {
 	if t.With.PayloadTemplateURL != "" {
-		template, err := getTextTemplateFromURL(t.With.PayloadTemplateURL)
+		template, err := getTextTemplateFromURL(ctx, t.With.PayloadTemplateURL)
 		if err != nil {
 			return "", err
 		}
@@ -110,8 +115,8 @@ func (t *notifyTask) getPayload(exp *Experiment) (string, error) {
 	return "", nil
 }
 
-// initializeDefaults sets default values for the custom metrics task
-func (t *notifyTask) initializeDefaults() {
+// InitializeDefaults sets default values for the custom metrics task
+func (t *notifyTask) InitializeDefaults() {
 	// set default HTTP method
 	if t.With.Method == "" {
 		if t.With.PayloadTemplateURL != "" {
@@ -122,32 +127,32 @@ func (t *notifyTask) initializeDefaults() {
 	}
 }
 
-// validate task inputs
-func (t *notifyTask) validateInputs() error {
+// ValidateInputs validates task inputs
+func (t *notifyTask) ValidateInputs() error {
+	errs := inputErrors{}
 	if t.With.URL == "" {
-		return errors.New("no URL was provided for notify task")
+		errs.add("with.url", "no URL was provided for notify task")
 	}
-
-	return nil
+	return errs.errOrNil()
 }
 
-// run executes this task
-func (t *notifyTask) run(exp *Experiment) error {
+// Run executes this task
+func (t *notifyTask) Run(ctx context.Context, exp *Experiment) error {
 	// validate inputs
-	err := t.validateInputs()
+	err := t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
 	// initialize defaults
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	var requestBody io.Reader
 
 	log.Logger.Debug("method: ", t.With.Method, " URL: ", t.With.URL)
 
 	if t.With.PayloadTemplateURL != "" {
-		payload, err := t.getPayload(exp)
+		payload, err := t.getPayload(ctx, exp)
 		if err != nil {
 			log.Logger.Error("could not get payload")
 			return err
@@ -159,7 +164,7 @@ func (t *notifyTask) run(exp *Experiment) error {
 	}
 
 	// create a new HTTP request
-	req, err := http.NewRequest(t.With.Method, t.With.URL, requestBody)
+	req, err := http.NewRequestWithContext(ctx, t.With.Method, t.With.URL, requestBody)
 	if err != nil {
 		log.Logger.Error("could not create HTTP request for notify task: ", err)
 
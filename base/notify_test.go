@@ -2,6 +2,7 @@ package base
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -50,7 +51,7 @@ func TestNotify(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err := nt.run(exp)
+	err := nt.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -124,7 +125,7 @@ func TestNotifyWithPayload(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err := nt.run(exp)
+	err := nt.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -166,7 +167,7 @@ func TestNotifyWithHeadersAndQueryParams(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err := nt.run(exp)
+	err := nt.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -188,7 +189,7 @@ func TestNotifyBadMethod(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err := nt.run(exp)
+	err := nt.Run(context.Background(), exp)
 
 	// test should fail
 	assert.Error(t, err)
@@ -206,7 +207,7 @@ func TestNotifyBadMethod(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err = nt.run(exp)
+	err = nt.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -252,7 +253,7 @@ func TestNotifyPayloadTemplateURLDefaultMethod(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err := nt.run(exp)
+	err := nt.Run(context.Background(), exp)
 
 	// test should not fail
 	assert.NoError(t, err)
@@ -272,7 +273,7 @@ func TestNotifyNoURL(t *testing.T) {
 	exp.initResults(1)
 	_ = exp.Result.initInsightsWithNumVersions(1)
 
-	err := nt.run(exp)
+	err := nt.Run(context.Background(), exp)
 
 	// test should fail
 	assert.Error(t, err)
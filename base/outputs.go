@@ -0,0 +1,245 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/itchyny/gojq"
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// outputTask is implemented by tasks that produce output which can be published to later tasks
+type outputTask interface {
+	// getOutput returns the output of the latest run of the task
+	// the output is JSON-like data, made up of maps, slices, strings, numbers, and booleans
+	getOutput() interface{}
+}
+
+// templatingTask is implemented by tasks that render templates in some of their inputs when they run
+// Only references to outputs, such as {{ .Outputs.token }}, are substituted in these inputs before the task runs
+type templatingTask interface {
+	// ownsTemplate returns true if the input at the given path is a template rendered by the task
+	// the path is the sequence of keys and indices that lead to the input from the with inputs of the task
+	ownsTemplate(path []string) bool
+}
+
+// outputsData is the data used to render references to outputs in task inputs
+type outputsData struct {
+	// Outputs published by earlier tasks
+	Outputs map[string]string
+}
+
+// runTaskWithOutputs renders references to task outputs in the inputs of the task,
+// runs the task, and publishes the outputs of the task
+func runTaskWithOutputs(ctx context.Context, t Task, exp *Experiment) (int, error) {
+	rt, err := renderOutputs(t, exp.Result.Outputs)
+	if err != nil {
+		return 0, err
+	}
+	attempts, err := runTaskWithPolicy(ctx, rt, exp)
+	if err != nil {
+		return attempts, err
+	}
+	return attempts, publishOutputs(rt, exp)
+}
+
+// renderOutputs returns the task with references to outputs in its with inputs substituted
+// References use Go template syntax; for example, {{ .Outputs.token }}
+// The given task is left unchanged, so that it can be rendered again in later loops
+func renderOutputs(t Task, outputs map[string]string) (Task, error) {
+	tv := reflect.ValueOf(t)
+	if tv.Kind() != reflect.Pointer || tv.Elem().Kind() != reflect.Struct {
+		return t, nil
+	}
+	f, ok := jsonFields(tv.Elem().Type())["with"]
+	if !ok {
+		return t, nil
+	}
+	with := tv.Elem().FieldByName(f.Name)
+
+	b, err := json.Marshal(with.Interface())
+	if err != nil || !bytes.Contains(b, []byte("{{")) {
+		return t, nil
+	}
+
+	var v interface{}
+	if err = json.Unmarshal(b, &v); err != nil {
+		return t, nil
+	}
+	data := outputsData{Outputs: outputs}
+	if data.Outputs == nil {
+		data.Outputs = map[string]string{}
+	}
+	owns := func([]string) bool { return false }
+	if tt, ok := t.(templatingTask); ok {
+		owns = tt.ownsTemplate
+	}
+	if v, err = renderValue(v, data, nil, owns); err != nil {
+		e := fmt.Errorf("unable to substitute outputs in inputs of task %v", *getName(t))
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	if b, err = json.Marshal(v); err != nil {
+		return nil, err
+	}
+
+	// copy the task, including unexported fields, and replace its inputs with the rendered inputs
+	cp := reflect.New(tv.Elem().Type())
+	cp.Elem().Set(tv.Elem())
+	cw := cp.Elem().FieldByName(f.Name)
+	cw.Set(reflect.Zero(cw.Type()))
+	if err = json.Unmarshal(b, cw.Addr().Interface()); err != nil {
+		e := fmt.Errorf("invalid inputs for task %v after substituting outputs", *getName(t))
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	return cp.Interface().(Task), nil
+}
+
+// renderValue executes every string in v that contains a template against data;
+// path is the location of v in the inputs of the task, and only references to outputs are substituted in templates that the task owns
+func renderValue(v interface{}, data outputsData, path []string, owns func([]string) bool) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		if !strings.Contains(val, "{{") {
+			return val, nil
+		}
+		if owns(path) {
+			return renderOutputReferences(val, data)
+		}
+		tpl, err := template.New("input").Option("missingkey=error").Parse(val)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err = tpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	case map[string]interface{}:
+		for k, e := range val {
+			r, err := renderValue(e, data, append(path[:len(path):len(path)], k), owns)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = r
+		}
+		return val, nil
+	case []interface{}:
+		for i, e := range val {
+			r, err := renderValue(e, data, append(path[:len(path):len(path)], strconv.Itoa(i)), owns)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = r
+		}
+		return val, nil
+	default:
+		return v, nil
+	}
+}
+
+// renderOutputReferences substitutes the actions in template s that are references to outputs, such as {{ .Outputs.token }}
+// other actions are left as they are, to be rendered by the task that owns the template
+func renderOutputReferences(s string, data outputsData) (string, error) {
+	tree := parse.New("input")
+	// functions are those of the task
+	tree.Mode = parse.SkipFuncCheck
+	if _, err := tree.Parse(s, "", "", map[string]*parse.Tree{}); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for _, node := range tree.Root.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			buf.Write(n.Text)
+		case *parse.ActionNode:
+			if !isOutputReference(n) {
+				buf.WriteString(n.String())
+				continue
+			}
+			tpl, err := template.New("input").Option("missingkey=error").Parse(n.String())
+			if err != nil {
+				return "", err
+			}
+			if err = tpl.Execute(&buf, data); err != nil {
+				return "", err
+			}
+		default:
+			buf.WriteString(n.String())
+		}
+	}
+	return buf.String(), nil
+}
+
+// isOutputReference returns true if the action is a reference to an output, such as {{ .Outputs.token }}
+func isOutputReference(n *parse.ActionNode) bool {
+	if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	f, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	return ok && len(f.Ident) == 2 && f.Ident[0] == "Outputs"
+}
+
+// publishOutputs evaluates the outputs declared by the task and adds them to the experiment result
+func publishOutputs(t Task, exp *Experiment) error {
+	tm := getTaskMeta(t)
+	if len(tm.Outputs) == 0 {
+		return nil
+	}
+	ot, ok := t.(outputTask)
+	if !ok {
+		err := fmt.Errorf("task %v does not produce outputs", *getName(t))
+		log.Logger.Error(err)
+		return err
+	}
+	out := ot.getOutput()
+
+	for name, query := range tm.Outputs {
+		val, err := evaluateOutput(query, out)
+		if err != nil {
+			e := fmt.Errorf("unable to evaluate output %v of task %v", name, *getName(t))
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return e
+		}
+		if exp.Result.Outputs == nil {
+			exp.Result.Outputs = map[string]string{}
+		}
+		exp.Result.Outputs[name] = val
+		log.Logger.Debug("published output ", name)
+	}
+	return nil
+}
+
+// evaluateOutput extracts a value from the task output using a jq query
+// strings are published as is; other values are published as JSON
+func evaluateOutput(query string, out interface{}) (string, error) {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return "", err
+	}
+	iter := q.Run(out)
+	v, ok := iter.Next()
+	if !ok {
+		return "", errors.New("query " + query + " returned no value")
+	}
+	if err, ok := v.(error); ok {
+		return "", err
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
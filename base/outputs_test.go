@@ -0,0 +1,112 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"fortio.org/fortio/fhttp"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRenderOutputs(t *testing.T) {
+	ct := &customTask{TaskMeta: TaskMeta{Task: StringPointer("custom")}}
+	ct.With.Message = "hello {{ .Outputs.name }}"
+
+	rt, err := renderOutputs(ct, map[string]string{"name": "world"})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", rt.(*customTask).With.Message)
+	// original task is unchanged
+	assert.Equal(t, "hello {{ .Outputs.name }}", ct.With.Message)
+
+	// missing output
+	_, err = renderOutputs(ct, nil)
+	assert.Error(t, err)
+
+	// nothing to render
+	ct.With.Message = "hello"
+	rt, err = renderOutputs(ct, nil)
+	assert.NoError(t, err)
+	assert.Same(t, ct, rt)
+}
+
+func TestRenderOutputsOwnedTemplates(t *testing.T) {
+	ct := &collectHTTPTask{
+		TaskMeta: TaskMeta{Task: StringPointer(CollectHTTPTaskName)},
+		With: collectHTTPInputs{
+			endpoint: endpoint{
+				Steps: []scenarioStep{{
+					Name:       "cart",
+					URL:        "http://{{ .Outputs.host }}/users/{{ .id }}/cart",
+					PayloadStr: StringPointer(`{{ omit . "id" | toJson }}{{ if .coupon }} {{ .Outputs.host }}{{ end }}`),
+				}},
+			},
+		},
+	}
+
+	// only references to outputs are substituted in templates of the task
+	rt, err := renderOutputs(ct, map[string]string{"host": "app"})
+	assert.NoError(t, err)
+	s := rt.(*collectHTTPTask).With.Steps[0]
+	assert.Equal(t, "http://app/users/{{.id}}/cart", s.URL)
+	assert.Equal(t, `{{omit . "id" | toJson}}{{if .coupon}} {{.Outputs.host}}{{end}}`, *s.PayloadStr)
+
+	// missing output
+	_, err = renderOutputs(ct, nil)
+	assert.Error(t, err)
+}
+
+func TestTaskOutputs(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	// create and configure HTTP endpoint for testing
+	mux, addr := fhttp.DynamicHTTPServer(false)
+	url := fmt.Sprintf("http://127.0.0.1:%d/get", addr.Port)
+	var verifyHandlerCalled bool
+	mux.HandleFunc("/get", GetTrackingHandler(&verifyHandlerCalled))
+
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+spec:
+- run: "echo '{\"url\": \"%v\", \"count\": 2}'"
+  outputs:
+    url: .url
+    count: .count
+- run: echo hello
+  outputs:
+    greeting: .
+- task: http
+  with:
+    url: "{{ .Outputs.url }}"
+    numRequests: 4
+`, url)), e)
+	assert.NoError(t, err)
+
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.True(t, verifyHandlerCalled)
+	assert.Equal(t, map[string]string{
+		"url":      url,
+		"count":    "2",
+		"greeting": "hello",
+	}, e.Result.Outputs)
+	// spec still refers to the output
+	assert.Equal(t, "{{ .Outputs.url }}", e.Spec[2].(*collectHTTPTask).With.URL)
+
+	// invalid query
+	e = &Experiment{}
+	err = yaml.Unmarshal([]byte(`
+spec:
+- run: echo hello
+  outputs:
+    greeting: .[
+`), e)
+	assert.NoError(t, err)
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.Error(t, err)
+	problems := e.Spec.Validate()
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, "spec[0].outputs.greeting", problems[0].Path)
+}
@@ -0,0 +1,229 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/iter8-tools/iter8/base/log"
+	"helm.sh/helm/v3/pkg/time"
+)
+
+const (
+	// ParallelTaskName is the name used for a block of tasks that run concurrently
+	ParallelTaskName = "parallel"
+)
+
+// parallelTask runs a block of tasks concurrently
+//
+// Each task in the block runs against its own, initially empty, result.
+// Once all tasks in the block have finished, the metrics they collected are merged
+// into the insights of the experiment.
+// The block fails if any of its tasks fail.
+type parallelTask struct {
+	// TaskMeta has fields common to all tasks
+	// if, timeout, and retries apply to the block as a whole
+	TaskMeta
+	// Parallel is the block of tasks
+	Parallel ExperimentSpec `json:"parallel" yaml:"parallel"`
+	// taskResults records the execution of each task in the block during the latest run of the block
+	taskResults []TaskResult
+}
+
+// InitializeDefaults sets default values for the tasks in the block
+func (t *parallelTask) InitializeDefaults() {}
+
+// ValidateInputs for this task
+func (t *parallelTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if len(t.Parallel) == 0 {
+		errs.add("parallel", "parallel block has no tasks")
+	}
+	return errs.errOrNil()
+}
+
+// Run executes the tasks in the block concurrently
+func (t *parallelTask) Run(ctx context.Context, exp *Experiment) error {
+	err := t.ValidateInputs()
+	if err != nil {
+		return err
+	}
+
+	t.InitializeDefaults()
+
+	// decide which tasks need to run before any of them start
+	t.taskResults = make([]TaskResult, len(t.Parallel))
+	tasks := []Task{}
+	// index is the position in the block of each task that runs
+	index := []int{}
+	for j, child := range t.Parallel {
+		t.taskResults[j] = TaskResult{
+			Name:      fmt.Sprintf("%v[%v].%v", ParallelTaskName, j, *getName(child)),
+			StartTime: time.Now(),
+		}
+		shouldRun, err := evaluateIf(child, exp)
+		if err != nil {
+			t.taskResults[j] = completeTaskResult(t.taskResults[j], TaskFailed, err)
+			t.taskResults = t.taskResults[:j+1]
+			return err
+		}
+		if !shouldRun {
+			t.taskResults[j] = completeTaskResult(t.taskResults[j], TaskSkipped, nil)
+			log.Logger.WithStackTrace(fmt.Sprint("false condition: ", *getIf(child))).Info("parallel task " + *getName(child) + ": skipped")
+			continue
+		}
+		tasks = append(tasks, child)
+		index = append(index, j)
+	}
+
+	// stop the remaining tasks as soon as one of them fails
+	blockCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*Experiment, len(tasks))
+	succeeded := make([]bool, len(tasks))
+	// firstErr is the failure that caused the block to be stopped
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, child := range tasks {
+		results[i] = exp.shadow()
+		wg.Add(1)
+		go func(i int, child Task) {
+			defer wg.Done()
+			log.Logger.Info("parallel task " + *getName(child) + ": started")
+			tr := &t.taskResults[index[i]]
+			tr.StartTime = time.Now()
+			attempts, err := runTaskWithOutputs(blockCtx, child, results[i])
+			tr.Attempts = attempts
+			if err != nil {
+				*tr = completeTaskResult(*tr, TaskFailed, err)
+				log.Logger.Error("parallel task " + *getName(child) + ": failure")
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			*tr = completeTaskResult(*tr, TaskSucceeded, nil)
+			succeeded[i] = true
+			log.Logger.Info("parallel task " + *getName(child) + ": completed")
+		}(i, child)
+	}
+	wg.Wait()
+
+	// merge metrics and outputs from the tasks that succeeded
+	for i := range tasks {
+		if !succeeded[i] {
+			continue
+		}
+		if err = exp.Result.mergeInsights(results[i].Result.Insights); err != nil {
+			return err
+		}
+		for name, val := range results[i].Result.Outputs {
+			if exp.Result.Outputs == nil {
+				exp.Result.Outputs = map[string]string{}
+			}
+			exp.Result.Outputs[name] = val
+		}
+	}
+
+	return firstErr
+}
+
+// shadow creates an experiment with an empty result that can be used by a task in a parallel block
+func (exp *Experiment) shadow() *Experiment {
+	return &Experiment{
+		Spec: exp.Spec,
+		Result: &ExperimentResult{
+			Revision:          exp.Result.Revision,
+			StartTime:         exp.Result.StartTime,
+			NumLoops:          exp.Result.NumLoops,
+			NumCompletedTasks: exp.Result.NumCompletedTasks,
+			Iter8Version:      exp.Result.Iter8Version,
+			Outputs:           copyOutputs(exp.Result.Outputs),
+		},
+		driver: exp.driver,
+	}
+}
+
+// copyOutputs returns a copy of the outputs published by tasks
+func copyOutputs(outputs map[string]string) map[string]string {
+	if outputs == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(outputs))
+	for k, v := range outputs {
+		cp[k] = v
+	}
+	return cp
+}
+
+// mergeInsights merges metrics and assessments from the given insights into the result
+func (r *ExperimentResult) mergeInsights(other *Insights) error {
+	if other == nil {
+		return nil
+	}
+	if err := r.initInsightsWithNumVersions(other.NumVersions); err != nil {
+		return err
+	}
+	in := r.Insights
+
+	if len(other.VersionNames) > 0 {
+		in.VersionNames = other.VersionNames
+	}
+
+	for m, mm := range other.MetricsInfo {
+		if err := in.registerMetric(m, mm); err != nil {
+			return err
+		}
+		for i := 0; i < in.NumVersions; i++ {
+			switch mm.Type {
+			case HistogramMetricType:
+				if vals, ok := other.HistMetricValues[i][m]; ok {
+					in.updateMetricValueHist(m, i, vals)
+				}
+			case SummaryMetricType:
+				if val, ok := other.SummaryMetricValues[i][m]; ok {
+					in.updateSummaryMetric(m, i, &val)
+				}
+			case SampleMetricType:
+				if vals, ok := other.NonHistMetricValues[i][m]; ok {
+					in.updateMetricValueVector(m, i, vals)
+				}
+			default:
+				loops := other.metricLoops(i, m)
+				for k, val := range other.NonHistMetricValues[i][m] {
+					loop := r.NumLoops
+					if loops != nil {
+						loop = loops[k]
+					}
+					in.appendMetricValueScalar(m, i, val, loop)
+				}
+			}
+		}
+	}
+
+	if other.SLOs != nil {
+		if err := in.setSLOs(other.SLOs); err != nil {
+			return err
+		}
+		in.SLOsSatisfied = other.SLOsSatisfied
+		in.EffectiveSLOLimits = other.EffectiveSLOLimits
+	}
+	if other.Rewards != nil {
+		if err := in.setRewards(other.Rewards); err != nil {
+			return err
+		}
+		in.RewardsWinners = other.RewardsWinners
+	}
+	if other.CanaryScores != nil {
+		in.CanaryScores = other.CanaryScores
+	}
+	if other.Regressions != nil {
+		in.Regressions = other.Regressions
+	}
+	return nil
+}
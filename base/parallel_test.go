@@ -0,0 +1,113 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestRunParallel(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- parallel:
+  - run: sleep 1
+  - run: sleep 1
+  - run: exit 1
+    if: "false"
+`), e)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(e.Spec))
+	pt, ok := e.Spec[0].(*parallelTask)
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(pt.Parallel))
+	assert.Equal(t, ParallelTaskName, *getName(pt))
+
+	// tasks run concurrently, skipped task does not fail the block
+	start := time.Now()
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.True(t, e.Completed())
+	assert.True(t, e.NoFailure())
+
+	// every task in the block is recorded
+	trs := e.Result.TaskResults[0].Parallel
+	assert.Equal(t, 3, len(trs))
+	assert.Equal(t, "parallel[0].run", trs[0].Name)
+	assert.Equal(t, TaskSucceeded, trs[0].Status)
+	assert.Equal(t, 1, trs[0].Attempts)
+	assert.Equal(t, "parallel[1].run", trs[1].Name)
+	assert.Equal(t, TaskSucceeded, trs[1].Status)
+	assert.Equal(t, "parallel[2].run", trs[2].Name)
+	assert.Equal(t, TaskSkipped, trs[2].Status)
+	assert.Equal(t, 0, trs[2].Attempts)
+
+	// failing task fails the block and stops the others
+	e = &Experiment{}
+	err = yaml.Unmarshal([]byte(`
+spec:
+- parallel:
+  - run: sleep 10
+  - run: exit 1
+`), e)
+	assert.NoError(t, err)
+	start = time.Now()
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.False(t, e.NoFailure())
+
+	// the failed task is recorded within the failed block
+	assert.Equal(t, TaskFailed, e.Result.TaskResults[0].Status)
+	trs = e.Result.TaskResults[0].Parallel
+	assert.Equal(t, 2, len(trs))
+	assert.Equal(t, TaskFailed, trs[1].Status)
+	assert.Equal(t, "exit status 1", trs[1].Error)
+
+	// empty block is invalid
+	pt = &parallelTask{}
+	assert.Error(t, pt.ValidateInputs())
+}
+
+func TestMergeInsights(t *testing.T) {
+	exp := &Experiment{}
+	exp.initResults(1)
+	exp.Result.NumLoops = 2
+
+	// metrics collected by two tasks in a parallel block
+	a := exp.shadow()
+	_ = a.Result.initInsightsWithNumVersions(1)
+	err := a.Result.Insights.updateMetric("a/counter", MetricMeta{Type: CounterMetricType}, 0, float64(10))
+	assert.NoError(t, err)
+	err = a.Result.Insights.updateMetric("a/hist", MetricMeta{Type: HistogramMetricType}, 0, []HistBucket{{Lower: 0, Upper: 1, Count: 2}})
+	assert.NoError(t, err)
+
+	b := exp.shadow()
+	_ = b.Result.initInsightsWithNumVersions(1)
+	err = b.Result.Insights.updateMetric("b/sample", MetricMeta{Type: SampleMetricType}, 0, []float64{1, 2, 3})
+	assert.NoError(t, err)
+
+	assert.NoError(t, exp.Result.mergeInsights(a.Result.Insights))
+	assert.NoError(t, exp.Result.mergeInsights(b.Result.Insights))
+	assert.NoError(t, exp.Result.mergeInsights(nil))
+
+	in := exp.Result.Insights
+	assert.Equal(t, 1, in.NumVersions)
+	assert.Equal(t, 3, len(in.MetricsInfo))
+	assert.Equal(t, float64(10), *in.ScalarMetricValue(0, "a/counter"))
+	assert.Equal(t, []int{2}, in.NonHistMetricLoops[0]["a/counter"])
+	assert.Equal(t, float64(2), *in.ScalarMetricValue(0, "b/sample/mean"))
+	assert.Equal(t, 1, len(in.HistMetricValues[0]["a/hist"]))
+
+	// conflicting number of versions
+	c := exp.shadow()
+	_ = c.Result.initInsightsWithNumVersions(2)
+	assert.Error(t, exp.Result.mergeInsights(c.Result.Insights))
+}
@@ -0,0 +1,113 @@
+package base
+
+import (
+	"context"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// planner is implemented by tasks that resolve more than their inputs in a plan
+// For example, the custommetrics task renders the metric queries it would send
+type planner interface {
+	// plan returns the resolved values of the task without running it
+	plan(ctx context.Context, exp *Experiment) (interface{}, error)
+}
+
+// TaskPlan describes what a task would do if the experiment were run
+type TaskPlan struct {
+	// Name is the name of the task
+	Name string `json:"name" yaml:"name"`
+
+	// Run is true if the condition of the task is satisfied by the current experiment result
+	Run bool `json:"run" yaml:"run"`
+
+	// Note explains why the task would not run or why its plan is incomplete
+	Note string `json:"note,omitempty" yaml:"note,omitempty"`
+
+	// Task is the task with defaults applied and references to available outputs substituted in its inputs
+	// Parallel blocks are described by the plans of their tasks instead
+	Task Task `json:"task,omitempty" yaml:"task,omitempty"`
+
+	// Resolved are values computed from the inputs of the task, such as rendered metric queries
+	Resolved interface{} `json:"resolved,omitempty" yaml:"resolved,omitempty"`
+
+	// Parallel are the plans of the tasks in a parallel block
+	Parallel []TaskPlan `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+}
+
+// ExperimentPlan describes what each task in an experiment would do if the experiment were run
+type ExperimentPlan []TaskPlan
+
+// plan describes each task in the spec without running any of them
+func (s ExperimentSpec) plan(ctx context.Context, exp *Experiment) ExperimentPlan {
+	p := ExperimentPlan{}
+	for _, t := range s {
+		p = append(p, planTask(ctx, t, exp))
+	}
+	return p
+}
+
+// planTask describes what the task would do if it were run against the experiment
+func planTask(ctx context.Context, t Task, exp *Experiment) TaskPlan {
+	tp := TaskPlan{
+		Name: *getName(t),
+		Task: t,
+	}
+	addNote := func(note string) {
+		if len(tp.Note) > 0 {
+			tp.Note += "; "
+		}
+		tp.Note += note
+	}
+
+	shouldRun, err := evaluateIf(t, exp)
+	if err != nil {
+		addNote("condition cannot be evaluated: " + err.Error())
+	} else if !shouldRun {
+		addNote("condition is false")
+	}
+	tp.Run = shouldRun
+
+	if err = t.ValidateInputs(); err != nil {
+		addNote("invalid inputs: " + err.Error())
+		return tp
+	}
+
+	// outputs of earlier tasks are only available if they are in the current result
+	rt, err := renderOutputs(t, exp.Result.Outputs)
+	if err != nil {
+		addNote("inputs reference outputs that are not available yet")
+		rt = t
+	}
+	rt.InitializeDefaults()
+	tp.Task = rt
+
+	if p, ok := rt.(planner); ok {
+		if tp.Resolved, err = p.plan(ctx, exp); err != nil {
+			addNote("unable to resolve task: " + err.Error())
+		}
+	}
+	if pt, ok := rt.(*parallelTask); ok {
+		tp.Task = nil
+		tp.Parallel = pt.Parallel.plan(ctx, exp)
+	}
+	return tp
+}
+
+// PlanExperiment describes what each task of an experiment would do without running the experiment
+// Defaults are applied to the inputs of each task, and conditions are evaluated against the current result
+// No load is generated, and no metric queries or notifications are sent; nothing is written by the driver
+func PlanExperiment(ctx context.Context, reuseResult bool, driver Driver) (ExperimentPlan, error) {
+	var exp *Experiment
+	var err error
+	if exp, err = BuildExperiment(driver); err != nil {
+		return nil, err
+	}
+	if !reuseResult || exp.Result == nil {
+		exp.initResults(driver.GetRevision())
+	}
+	// conditions see the loop that would run next
+	exp.incrementNumLoops()
+	log.Logger.Debugf("planning %v tasks", len(exp.Spec))
+	return exp.Spec.plan(ctx, exp), nil
+}
@@ -0,0 +1,91 @@
+package base
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestPlanExperiment(t *testing.T) {
+	dat, err := os.ReadFile(CompletePath("../testdata/custommetrics", "istio-prom.tpl"))
+	assert.NoError(t, err)
+
+	_ = os.Chdir(t.TempDir())
+	httpmock.Activate()
+	t.Cleanup(httpmock.DeactivateAndReset)
+	httpmock.RegisterResponder("GET", istioPromProviderURL, httpmock.NewStringResponder(200, string(dat)))
+
+	e := &Experiment{}
+	err = yaml.Unmarshal([]byte(`
+spec:
+- task: http
+  with:
+    url: https://httpbin.org/get
+- task: custommetrics
+  with:
+    templates:
+      istio-prom: `+istioPromProviderURL+`
+    values:
+      latencyPercentiles: ["90"]
+    versionValues:
+    - labels:
+        reporter: destination
+        destination_workload: myApp
+        destination_workload_namespace: production
+      elapsedTimeSeconds: "5"
+- task: notify
+  with:
+    url: "{{ .Outputs.hook }}"
+- if: loop() > 1
+  run: echo second loop
+- parallel:
+  - run: echo hello
+  - task: notify
+`), e)
+	assert.NoError(t, err)
+
+	plan, err := PlanExperiment(context.Background(), false, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(plan))
+
+	// defaults are applied to task inputs
+	assert.True(t, plan[0].Run)
+	ht := plan[0].Task.(*collectHTTPTask)
+	assert.Equal(t, defaultQPS, *ht.With.QPS)
+	assert.Equal(t, defaultHTTPConnections, *ht.With.Connections)
+
+	// metric queries are rendered but not sent
+	providers := plan[1].Resolved.(map[string][]ProviderSpec)
+	assert.Equal(t, 1, len(providers["istio-prom"]))
+	found := false
+	for _, m := range providers["istio-prom"][0].Metrics {
+		if m.Name == "request-count" {
+			found = true
+			assert.Equal(t, istioPromRequestCount, strings.TrimSpace((*m.Params)[0].Value))
+		}
+	}
+	assert.True(t, found)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET http://prometheus.istio-system:9090/api/v1/query"])
+
+	// outputs of earlier tasks are not available
+	assert.Contains(t, plan[2].Note, "outputs")
+
+	// conditions are evaluated against the loop that would run next
+	assert.False(t, plan[3].Run)
+	assert.Equal(t, "condition is false", plan[3].Note)
+
+	// tasks in parallel blocks are planned individually
+	assert.Nil(t, plan[4].Task)
+	assert.Equal(t, 2, len(plan[4].Parallel))
+	assert.Empty(t, plan[4].Parallel[0].Note)
+	assert.Contains(t, plan[4].Parallel[1].Note, "invalid inputs")
+
+	// the plan can be printed
+	_, err = yaml.Marshal(plan)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,125 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+const (
+	// defaultBackoff is the default duration to wait before retrying a failed task
+	defaultBackoff = "1s"
+)
+
+// taskPolicy captures the timeout and retry settings of a task
+type taskPolicy struct {
+	// timeout is the maximum duration of a single attempt; zero means no timeout
+	timeout time.Duration
+	// retries is the number of times a failed attempt is retried
+	retries int
+	// backoff is the duration to wait before the first retry
+	backoff time.Duration
+}
+
+// getTaskPolicy parses the timeout and retry settings in task meta
+func getTaskPolicy(tm TaskMeta) (*taskPolicy, error) {
+	p := &taskPolicy{}
+	var err error
+	if tm.Timeout != nil {
+		if p.timeout, err = time.ParseDuration(*tm.Timeout); err != nil {
+			e := fmt.Errorf("invalid format for task timeout: %v", *tm.Timeout)
+			log.Logger.WithStackTrace(err.Error()).Error(e)
+			return nil, e
+		}
+		if p.timeout <= 0 {
+			e := fmt.Errorf("task timeout must be positive: %v", *tm.Timeout)
+			log.Logger.Error(e)
+			return nil, e
+		}
+	}
+	if tm.Retries != nil {
+		if *tm.Retries < 0 {
+			e := fmt.Errorf("task retries cannot be negative: %v", *tm.Retries)
+			log.Logger.Error(e)
+			return nil, e
+		}
+		p.retries = *tm.Retries
+	}
+	backoff := defaultBackoff
+	if tm.Backoff != nil {
+		backoff = *tm.Backoff
+	}
+	if p.backoff, err = time.ParseDuration(backoff); err != nil {
+		e := fmt.Errorf("invalid format for task backoff: %v", backoff)
+		log.Logger.WithStackTrace(err.Error()).Error(e)
+		return nil, e
+	}
+	if p.backoff <= 0 {
+		e := fmt.Errorf("task backoff must be positive: %v", backoff)
+		log.Logger.Error(e)
+		return nil, e
+	}
+	return p, nil
+}
+
+// runTaskWithPolicy runs a task, enforcing its timeout and retrying failed attempts
+// retries stop once ctx is done
+// metric values observed by failed attempts are discarded
+// the number of attempts made is returned along with the error of the last attempt
+func runTaskWithPolicy(ctx context.Context, t Task, exp *Experiment) (int, error) {
+	p, err := getTaskPolicy(getTaskMeta(t))
+	if err != nil {
+		return 0, err
+	}
+
+	backoff := p.backoff
+	for attempt := 1; ; attempt++ {
+		mark := exp.Result.markInsights()
+		err = runTaskAttempt(ctx, t, exp, p.timeout)
+		if err == nil {
+			return attempt, nil
+		}
+		// discard the metric values observed by the failed attempt,
+		// so that they are not counted again when the task is retried or resumed
+		exp.Result.rollbackInsights(mark)
+		if attempt > p.retries || ctx.Err() != nil {
+			return attempt, err
+		}
+		log.Logger.WithStackTrace(err.Error()).Warnf("attempt %v of task %v failed; retrying in %v", attempt, *getName(t), backoff)
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// runTaskAttempt runs a task once; if timeout is positive, the attempt fails once the timeout elapses
+// the task is given a context that is cancelled when the attempt times out or when ctx is done
+func runTaskAttempt(ctx context.Context, t Task, exp *Experiment, timeout time.Duration) error {
+	if timeout <= 0 {
+		return t.Run(ctx, exp)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// wait for the task to return even if it times out,
+	// so that an abandoned attempt cannot modify results while the task is retried or later tasks run
+	err := t.Run(attemptCtx, exp)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return timeoutError(timeout)
+	}
+	return err
+}
+
+// timeoutError is the error returned when a task attempt times out
+func timeoutError(timeout time.Duration) error {
+	err := errors.New("task timed out after " + timeout.String())
+	log.Logger.Error(err)
+	return err
+}
@@ -0,0 +1,159 @@
+package base
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTaskPolicy(t *testing.T) {
+	// defaults
+	p, err := getTaskPolicy(TaskMeta{})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), p.timeout)
+	assert.Equal(t, 0, p.retries)
+	assert.Equal(t, time.Second, p.backoff)
+
+	// explicit values
+	p, err = getTaskPolicy(TaskMeta{
+		Timeout: StringPointer("30s"),
+		Retries: intPointer(3),
+		Backoff: StringPointer("100ms"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, p.timeout)
+	assert.Equal(t, 3, p.retries)
+	assert.Equal(t, 100*time.Millisecond, p.backoff)
+
+	// invalid values
+	_, err = getTaskPolicy(TaskMeta{Timeout: StringPointer("abc")})
+	assert.Error(t, err)
+	_, err = getTaskPolicy(TaskMeta{Timeout: StringPointer("-1s")})
+	assert.Error(t, err)
+	_, err = getTaskPolicy(TaskMeta{Retries: intPointer(-1)})
+	assert.Error(t, err)
+	_, err = getTaskPolicy(TaskMeta{Backoff: StringPointer("abc")})
+	assert.Error(t, err)
+	_, err = getTaskPolicy(TaskMeta{Timeout: StringPointer("0s")})
+	assert.EqualError(t, err, "task timeout must be positive: 0s")
+	_, err = getTaskPolicy(TaskMeta{Backoff: StringPointer("0s")})
+	assert.EqualError(t, err, "task backoff must be positive: 0s")
+	_, err = getTaskPolicy(TaskMeta{Backoff: StringPointer("-1s")})
+	assert.Error(t, err)
+}
+
+func TestRunTaskWithRetries(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	// fails on the first two attempts and succeeds on the third
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run:     StringPointer("echo x >> attempts.txt; test $(wc -l < attempts.txt) -ge 3"),
+			Retries: intPointer(2),
+			Backoff: StringPointer("10ms"),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{rt},
+	}
+	exp.initResults(1)
+	attempts, err := runTaskWithPolicy(context.Background(), rt, exp)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	b, err := os.ReadFile("attempts.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(b), "x"))
+
+	// retries are exhausted
+	_ = os.Remove("attempts.txt")
+	rt.Retries = intPointer(1)
+	attempts, err = runTaskWithPolicy(context.Background(), rt, exp)
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+	b, err = os.ReadFile("attempts.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(b), "x"))
+}
+
+func TestRunTaskWithTimeout(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run:     StringPointer("sleep 10"),
+			Timeout: StringPointer("200ms"),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{rt},
+	}
+	exp.initResults(1)
+
+	start := time.Now()
+	err := RunExperiment(context.Background(), false, &mockDriver{exp})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.False(t, exp.NoFailure())
+}
+
+func TestRunTaskWithTimeoutKillsChildProcesses(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	// bash forks sleep instead of exec'ing it, and sleep holds the output pipe open
+	rt := &runTask{
+		TaskMeta: TaskMeta{
+			Run:     StringPointer("sleep 10; echo hello"),
+			Timeout: StringPointer("200ms"),
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{rt},
+	}
+	exp.initResults(1)
+
+	start := time.Now()
+	err := RunExperiment(context.Background(), false, &mockDriver{exp})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.False(t, exp.NoFailure())
+}
+
+// slowTask records metric values after its context is done, like a load test that is stopped
+type slowTask struct {
+	customTask
+	// returned is the number of attempts that returned
+	returned int
+}
+
+func (t *slowTask) Run(ctx context.Context, exp *Experiment) error {
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+	_ = exp.Result.Insights.updateMetric("slow/count", MetricMeta{Type: CounterMetricType}, 0, float64(1))
+	t.returned++
+	return ctx.Err()
+}
+
+func TestRunTaskWithTimeoutWaitsForAttempts(t *testing.T) {
+	st := &slowTask{}
+	st.Task = StringPointer("slow")
+	st.Timeout = StringPointer("10ms")
+	st.Retries = intPointer(1)
+	st.Backoff = StringPointer("1ms")
+	exp := &Experiment{
+		Spec: []Task{st},
+	}
+	exp.initResults(1)
+	assert.NoError(t, exp.Result.initInsightsWithNumVersions(1))
+
+	attempts, err := runTaskWithPolicy(context.Background(), st, exp)
+	assert.EqualError(t, err, "task timed out after 10ms")
+	assert.Equal(t, 2, attempts)
+	// both attempts returned before the task failed, and their metric values were discarded
+	assert.Equal(t, 2, st.returned)
+	assert.NotContains(t, exp.Result.Insights.MetricsInfo, "slow/count")
+	assert.NotContains(t, exp.Result.Insights.NonHistMetricValues[0], "slow/count")
+}
@@ -16,7 +16,6 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/util/retry"
 )
 
 const (
@@ -52,8 +51,8 @@ type readinessTask struct {
 	With readinessInputs `json:"with" yaml:"with"`
 }
 
-// initializeDefaults sets default values for the readiness task
-func (t *readinessTask) initializeDefaults() {
+// InitializeDefaults sets default values for the readiness task
+func (t *readinessTask) InitializeDefaults() {
 	if t.With.Timeout == nil {
 		t.With.Timeout = StringPointer(defaultTimeout)
 	}
@@ -64,26 +63,39 @@ func (t *readinessTask) initializeDefaults() {
 	}
 }
 
-// validateInputs validates task inputs
-// at present all validation at initialization
-func (t *readinessTask) validateInputs() error {
-	return nil
+// ValidateInputs validates task inputs
+func (t *readinessTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if len(t.With.Resource) == 0 {
+		errs.add("with.resource", "resource is required")
+	}
+	if len(t.With.Name) == 0 {
+		errs.add("with.name", "name is required")
+	}
+	if t.With.Timeout != nil {
+		if d, err := time.ParseDuration(*t.With.Timeout); err != nil {
+			errs.add("with.timeout", "invalid timeout %v", *t.With.Timeout)
+		} else if d <= 0 {
+			errs.add("with.timeout", "timeout must be positive")
+		}
+	}
+	return errs.errOrNil()
 }
 
-// run executes the task
-func (t *readinessTask) run(exp *Experiment) error {
+// Run executes the task
+func (t *readinessTask) Run(ctx context.Context, exp *Experiment) error {
 	// validation
-	err := t.validateInputs()
+	err := t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
-	// kd is required by initializeDefaults
+	// kd is required by InitializeDefaults
 	if err = kd.initKube(); err != nil {
 		return err
 	}
 	// initialize default values
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	// parse timeout
 	timeout, err := time.ParseDuration(*t.With.Timeout)
@@ -102,9 +114,10 @@ func (t *readinessTask) run(exp *Experiment) error {
 	}
 
 	// do the work: check for object and condition
-	// repeat until time out
+	// repeat until time out or until ctx is done
 	interval := 1 * time.Second
-	err = retry.OnError(
+	var lastErr error
+	err = wait.ExponentialBackoffWithContext(ctx,
 		wait.Backoff{
 			Steps:    int(timeout / interval),
 			Cap:      timeout,
@@ -112,23 +125,28 @@ func (t *readinessTask) run(exp *Experiment) error {
 			Factor:   1.0,
 			Jitter:   0.1,
 		},
-		func(err error) bool {
-			log.Logger.Error(err)
-			return true
-		}, // retry on all failures
-		func() error {
-			return checkObjectExistsAndConditionTrue(t, restConfig)
+		func() (bool, error) {
+			// retry on all failures
+			if lastErr = checkObjectExistsAndConditionTrue(ctx, t, restConfig); lastErr != nil {
+				log.Logger.Error(lastErr)
+				return false, nil
+			}
+			return true, nil
 		},
 	)
+	// report the last failure rather than a generic timeout
+	if errors.Is(err, wait.ErrWaitTimeout) && lastErr != nil {
+		return lastErr
+	}
 	return err
 }
 
 // checkObjectExistsAndConditionTrue determines if the object exists
 // if so, it further checks if the requested condition is "True"
-func checkObjectExistsAndConditionTrue(t *readinessTask, restCfg *rest.Config) error {
+func checkObjectExistsAndConditionTrue(ctx context.Context, t *readinessTask, restCfg *rest.Config) error {
 	log.Logger.Trace("looking for resource (", t.With.Group, "/", t.With.Version, ") ", t.With.Resource, ": ", t.With.Name, " in namespace ", *t.With.Namespace)
 
-	obj, err := kd.dynamicClient.Resource(gvr(&t.With)).Namespace(*t.With.Namespace).Get(context.Background(), t.With.Name, metav1.GetOptions{})
+	obj, err := kd.dynamicClient.Resource(gvr(&t.With)).Namespace(*t.With.Namespace).Get(ctx, t.With.Name, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
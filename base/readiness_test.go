@@ -83,7 +83,7 @@ func runTaskTest(t *testing.T, rTask *readinessTask, success bool, ns string, po
 	_, err := kd.dynamicClient.Resource(rs).Namespace(ns).Create(context.Background(), pod, metav1.CreateOptions{})
 	assert.NoError(t, err, "get failed")
 
-	err = rTask.run(&Experiment{
+	err = rTask.Run(context.Background(), &Experiment{
 		Spec:   []Task{rTask},
 		Result: &ExperimentResult{},
 	})
@@ -0,0 +1,100 @@
+package base
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	log "github.com/iter8-tools/iter8/base/log"
+)
+
+// TaskFactory creates a new task with zero-valued inputs
+// Task specs in an experiment are unmarshaled into the task returned by the factory,
+// so the factory must return a pointer to the task struct, and the task struct must embed TaskMeta
+type TaskFactory func() Task
+
+var (
+	// taskRegistry maps task names to their factories
+	taskRegistry = map[string]TaskFactory{}
+	// taskRegistryMutex guards taskRegistry
+	taskRegistryMutex sync.RWMutex
+)
+
+func init() {
+	// register built-in tasks
+	builtInTasks := map[string]TaskFactory{
+		ReadinessTaskName:         func() Task { return &readinessTask{} },
+		CustomMetricsTaskName:     func() Task { return &customMetricsTask{} },
+		CollectHTTPTaskName:       func() Task { return &collectHTTPTask{} },
+		CollectGRPCTaskName:       func() Task { return &collectGRPCTask{} },
+		CollectABNMetricsTaskName: func() Task { return &collectABNMetricsTask{} },
+		DerivedMetricsTaskName:    func() Task { return &derivedMetricsTask{} },
+		AssessTaskName:            func() Task { return &assessTask{} },
+		NotifyTaskName:            func() Task { return &notifyTask{} },
+	}
+	for name, factory := range builtInTasks {
+		if err := RegisterTask(name, factory); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterTask makes a task available in experiment specs under the given name
+// Binaries that embed Iter8 can use this to add their own tasks, typically from an init function
+// A task name can be registered only once; the names of the run task and parallel blocks are reserved
+func RegisterTask(name string, factory TaskFactory) error {
+	if len(name) == 0 {
+		err := fmt.Errorf("task name cannot be empty")
+		log.Logger.Error(err)
+		return err
+	}
+	if name == RunTaskName || name == ParallelTaskName {
+		err := fmt.Errorf("task name %v is reserved", name)
+		log.Logger.Error(err)
+		return err
+	}
+	if factory == nil {
+		err := fmt.Errorf("nil factory for task %v", name)
+		log.Logger.Error(err)
+		return err
+	}
+	if err := checkTaskFactory(name, factory); err != nil {
+		log.Logger.Error(err)
+		return err
+	}
+
+	taskRegistryMutex.Lock()
+	defer taskRegistryMutex.Unlock()
+	if _, ok := taskRegistry[name]; ok {
+		err := fmt.Errorf("task %v is already registered", name)
+		log.Logger.Error(err)
+		return err
+	}
+	taskRegistry[name] = factory
+	return nil
+}
+
+// checkTaskFactory checks that tasks created by the factory carry TaskMeta
+// the name of a task is read from its TaskMeta, so tasks without it cannot be run
+func checkTaskFactory(name string, factory TaskFactory) error {
+	t := factory()
+	if t == nil {
+		return fmt.Errorf("factory for task %v returned nil", name)
+	}
+	b, _ := json.Marshal(TaskMeta{Task: StringPointer(name)})
+	if err := json.Unmarshal(b, t); err != nil {
+		return fmt.Errorf("factory for task %v must return a pointer to a struct: %v", name, err)
+	}
+	if tm := getTaskMeta(t); tm.Task == nil || *tm.Task != name {
+		return fmt.Errorf("task %v must embed TaskMeta", name)
+	}
+	return nil
+}
+
+// getTaskFactory returns the factory registered for the given task name
+func getTaskFactory(name string) (TaskFactory, bool) {
+	taskRegistryMutex.RLock()
+	defer taskRegistryMutex.RUnlock()
+	factory, ok := taskRegistry[name]
+	return factory, ok
+}
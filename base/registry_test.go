@@ -0,0 +1,104 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+// customTask is a task defined outside of the built-in tasks
+type customTask struct {
+	TaskMeta
+	With struct {
+		Message string `json:"message" yaml:"message"`
+	} `json:"with" yaml:"with"`
+	ran bool
+}
+
+func (t *customTask) InitializeDefaults() {}
+
+func (t *customTask) ValidateInputs() error {
+	return nil
+}
+
+func (t *customTask) Run(ctx context.Context, exp *Experiment) error {
+	t.ran = true
+	return nil
+}
+
+// noMetaTask is a task that does not embed TaskMeta
+type noMetaTask struct {
+	With struct {
+		Message string `json:"message" yaml:"message"`
+	} `json:"with" yaml:"with"`
+}
+
+func (t *noMetaTask) InitializeDefaults() {}
+
+func (t *noMetaTask) ValidateInputs() error {
+	return nil
+}
+
+func (t *noMetaTask) Run(ctx context.Context, exp *Experiment) error {
+	return nil
+}
+
+func TestRegisterTask(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+
+	err := RegisterTask("customtask", func() Task { return &customTask{} })
+	assert.NoError(t, err)
+
+	// duplicate registration should fail
+	err = RegisterTask("customtask", func() Task { return &customTask{} })
+	assert.Error(t, err)
+	err = RegisterTask(CollectHTTPTaskName, func() Task { return &customTask{} })
+	assert.Error(t, err)
+
+	// reserved, empty names and nil factories should fail
+	err = RegisterTask(RunTaskName, func() Task { return &customTask{} })
+	assert.Error(t, err)
+	err = RegisterTask("", func() Task { return &customTask{} })
+	assert.Error(t, err)
+	err = RegisterTask("nilfactory", nil)
+	assert.Error(t, err)
+
+	// tasks without TaskMeta cannot be named, and should fail
+	err = RegisterTask("nometa", func() Task { return &noMetaTask{} })
+	assert.EqualError(t, err, "task nometa must embed TaskMeta")
+	err = RegisterTask("nilmeta", func() Task { return nil })
+	assert.Error(t, err)
+	_, found := getTaskFactory("nometa")
+	assert.False(t, found)
+
+	// custom task can be used in an experiment spec
+	e := &Experiment{}
+	err = yaml.Unmarshal([]byte(`
+spec:
+- task: customtask
+  with:
+    message: hello
+`), e)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(e.Spec))
+	ct, ok := e.Spec[0].(*customTask)
+	assert.True(t, ok)
+	assert.Equal(t, "hello", ct.With.Message)
+
+	err = RunExperiment(context.Background(), false, &mockDriver{e})
+	assert.NoError(t, err)
+	assert.True(t, ct.ran)
+	assert.True(t, e.Completed())
+}
+
+func TestUnknownTask(t *testing.T) {
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- task: nosuchtask
+`), e)
+	assert.Error(t, err)
+}
@@ -1,9 +1,16 @@
 package base
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
 
 	log "github.com/iter8-tools/iter8/base/log"
 )
@@ -22,43 +29,114 @@ var (
 type runTask struct {
 	// TaskMeta has fields common to all tasks
 	TaskMeta
+	// stdout is the standard output of the latest run of the script
+	stdout []byte
 }
 
-// initializeDefaults sets default values for task inputs
-func (t *runTask) initializeDefaults() {}
+// InitializeDefaults sets default values for task inputs
+func (t *runTask) InitializeDefaults() {}
 
-// validateInputs for this task
-func (t *runTask) validateInputs() error {
-	return nil
+// ValidateInputs for this task
+func (t *runTask) ValidateInputs() error {
+	errs := inputErrors{}
+	if t.TaskMeta.Run == nil || len(strings.TrimSpace(*t.TaskMeta.Run)) == 0 {
+		errs.add("run", "run command is required")
+	}
+	return errs.errOrNil()
 }
 
 // getCommand gets the executable command
+// the command is started in its own process group, so that processes started by the script can be killed along with it
 func (t *runTask) getCommand() *exec.Cmd {
 	cmdStr := *t.TaskMeta.Run
 	// create command to be executed
 	// #nosec
 	cmd := exec.Command("/bin/bash", "-c", cmdStr)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
 	// append the environment variable for temp dir
 	cmd.Env = append(os.Environ(), tempDirEnv)
 	return cmd
 }
 
-// run the command
-func (t *runTask) run(exp *Experiment) error {
-	err := t.validateInputs()
+// runCommand runs the command and waits for it to complete
+// the process group of the command is killed if ctx is done before the command completes
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	killed := make(chan struct{})
+	go func() {
+		defer close(killed)
+		select {
+		case <-ctx.Done():
+			// child processes may hold the output pipes open, so kill the whole group
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	<-killed
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Run the command
+func (t *runTask) Run(ctx context.Context, exp *Experiment) error {
+	err := t.ValidateInputs()
 	if err != nil {
 		return err
 	}
 
-	t.initializeDefaults()
+	t.InitializeDefaults()
 
 	cmd := t.getCommand()
-	out, err := cmd.CombinedOutput()
+	// stdout is kept separately so that it can be published as output
+	// stdout and stderr are copied concurrently, so writes to the combined output are serialized
+	var stdout bytes.Buffer
+	combined := &lockedBuffer{}
+	cmd.Stdout = io.MultiWriter(&stdout, combined)
+	cmd.Stderr = combined
+	err = runCommand(ctx, cmd)
 	if err != nil {
 		log.Logger.WithStackTrace(err.Error()).Error("combined execution failed")
-		log.Logger.WithStackTrace(string(out)).Error("combined output from command")
+		log.Logger.WithStackTrace(combined.String()).Error("combined output from command")
 		return err
 	}
-	log.Logger.WithStackTrace(string(out)).Trace("combined output from command")
+	log.Logger.WithStackTrace(combined.String()).Trace("combined output from command")
+	t.stdout = stdout.Bytes()
 	return nil
 }
+
+// lockedBuffer is a buffer that can be written concurrently
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends p to the buffer
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the contents of the buffer
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// getOutput returns the standard output of the script
+// output that is valid JSON is returned as parsed JSON; any other output is returned as a string without surrounding whitespace
+func (t *runTask) getOutput() interface{} {
+	var v interface{}
+	if err := json.Unmarshal(t.stdout, &v); err == nil {
+		return v
+	}
+	return strings.TrimSpace(string(t.stdout))
+}
@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -21,6 +22,6 @@ func TestRunRun(t *testing.T) {
 		Result: &ExperimentResult{},
 	}
 	exp.initResults(1)
-	err := rt.run(exp)
+	err := rt.Run(context.Background(), exp)
 	assert.NoError(t, err)
 }
@@ -0,0 +1,126 @@
+package base
+
+import (
+	"math"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// SampleSize specifies how much data a version needs before its SLOs are evaluated
+// Versions with too little data for an SLO do not satisfy it, and are reported as having insufficient data
+type SampleSize struct {
+	// Min maps metric names to the minimum number of observations required to evaluate SLOs on the metric
+	//
+	// Observations of an aggregated metric, such as backend/metric/mean, are those of the sample, summary or histogram metric.
+	// Observations of other metrics are the requests counted by backend/request-count.
+	Min map[string]int `json:"min,omitempty" yaml:"min,omitempty"`
+
+	// Power is the statistical power required to evaluate SLOs on metrics that support significance tests
+	// A version needs enough observations to detect the difference between its metric value and the SLO limit with this power
+	Power *float64 `json:"power,omitempty" yaml:"power,omitempty"`
+
+	// Confidence is the confidence level used with Power
+	// Default value is 0.95
+	Confidence *float64 `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+}
+
+// validate checks the sample size requirements located at the given path
+func (ss *SampleSize) validate(errs *inputErrors, path string) {
+	for m, n := range ss.Min {
+		if n < 0 {
+			errs.add(joinPath(joinPath(path, "min"), m), "minimum number of observations cannot be negative")
+		}
+	}
+	if p := ss.Power; p != nil && (*p <= 0 || *p >= 1) {
+		errs.add(joinPath(path, "power"), "power must be between 0 and 1")
+	}
+	if c := ss.Confidence; c != nil && (*c <= 0 || *c >= 1) {
+		errs.add(joinPath(path, "confidence"), "confidence must be between 0 and 1")
+	}
+}
+
+// evaluateSampleSize returns the SLOs for which each version has insufficient data
+// insufficient[i][j] is true if version j has insufficient data to evaluate SLO i against the limit limits[i][j]
+func evaluateSampleSize(exp *Experiment, ss *SampleSize, slos []SLO, limits [][]*float64) [][]bool {
+	in := exp.Result.Insights
+	confidence := defaultRewardConfidence
+	if ss.Confidence != nil {
+		confidence = *ss.Confidence
+	}
+	insufficient := make([][]bool, len(slos))
+	for i, slo := range slos {
+		insufficient[i] = make([]bool, in.NumVersions)
+		for j := 0; j < in.NumVersions; j++ {
+			if minCount, ok := ss.Min[slo.Metric]; ok {
+				n := in.sampleCount(j, slo.Metric)
+				if n == nil || *n < float64(minCount) {
+					log.Logger.Warnf("insufficient data to evaluate SLO on metric %v for version %v", slo.Metric, j)
+					insufficient[i][j] = true
+					continue
+				}
+			}
+			if ss.Power != nil && limits[i][j] != nil {
+				s, test := in.getSampleStats(j, slo.Metric)
+				if len(test) > 0 && (s == nil || s.n < requiredSampleSize(s, test, *limits[i][j], confidence, *ss.Power)) {
+					log.Logger.Warnf("insufficient data to evaluate SLO on metric %v for version %v with power %v", slo.Metric, j, *ss.Power)
+					insufficient[i][j] = true
+				}
+			}
+		}
+	}
+	return insufficient
+}
+
+// sampleCount returns the number of observations behind the value of a metric for version i
+// nil is returned if the number of observations is unknown
+func (in *Insights) sampleCount(i int, m string) *float64 {
+	s := strings.Split(m, "/")
+	if len(s) == 3 {
+		baseMetric := s[0] + "/" + s[1]
+		mm, ok := in.MetricsInfo[baseMetric]
+		if !ok || i >= in.NumVersions {
+			return nil
+		}
+		switch mm.Type {
+		case SampleMetricType:
+			return float64Pointer(float64(len(in.NonHistMetricValues[i][baseMetric])))
+		case SummaryMetricType:
+			if sm, ok := in.SummaryMetricValues[i][baseMetric]; ok {
+				return float64Pointer(float64(sm.Count()))
+			}
+			return float64Pointer(0)
+		case HistogramMetricType:
+			return float64Pointer(histCount(mergeHistBuckets(in.HistMetricValues[i][baseMetric])))
+		}
+		return nil
+	}
+	if len(s) != 2 {
+		return nil
+	}
+	requests := s[0] + "/" + requestCountMetric
+	if !in.hasCounterOrGauge(requests) {
+		return nil
+	}
+	return in.getCounterOrGaugeMetricFromValuesMap(i, requests)
+}
+
+// requiredSampleSize returns the number of observations needed to detect the difference
+// between the mean of the observations and the limit, using a one-sided test with the given confidence and power
+func requiredSampleSize(s *sampleStats, test SignificanceTest, limit float64, confidence float64, power float64) float64 {
+	variance := s.variance
+	if test == TwoProportionTest {
+		// use the variance at the limit, so that rates with no events still need data
+		l := math.Min(math.Max(limit, 0), 1)
+		variance = math.Max(variance, l*(1-l))
+	}
+	if variance == 0 {
+		return 0
+	}
+	diff := math.Abs(limit - s.mean)
+	if diff == 0 {
+		return math.Inf(1)
+	}
+	z := quantile(normalCDF, confidence) + quantile(normalCDF, power)
+	return z * z * variance / (diff * diff)
+}
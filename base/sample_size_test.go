@@ -0,0 +1,81 @@
+package base
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSampleSize(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	exp := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- task: assess
+  with:
+    SLOs:
+      upper:
+      - metric: http/error-rate
+        limit: 0.01
+      - metric: a/latency/mean
+        limit: 20
+    sampleSize:
+      min:
+        http/error-rate: 100
+`), exp)
+	assert.NoError(t, err)
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	in := exp.Result.Insights
+	for i, v := range [][]float64{{3, 0}, {10000, 20}} {
+		assert.NoError(t, in.updateMetric("http/request-count", MetricMeta{Type: CounterMetricType}, i, v[0]))
+		assert.NoError(t, in.updateMetric("http/error-count", MetricMeta{Type: CounterMetricType}, i, v[1]))
+		assert.NoError(t, in.updateMetric("http/error-rate", MetricMeta{Type: GaugeMetricType}, i, v[1]/v[0]))
+	}
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: SampleMetricType}, 0, []float64{10, 12, 8}))
+	assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: SampleMetricType}, 1, []float64{19, 21, 20, 18, 22}))
+
+	// a version with 3 requests does not satisfy the error rate SLO
+	task := exp.Spec[0]
+	assert.NoError(t, task.Run(context.Background(), exp))
+	sr := in.SLOsSatisfied
+	assert.Equal(t, []bool{false, true}, sr.Upper[0])
+	assert.True(t, sr.InsufficientData(true, 0, 0))
+	assert.False(t, sr.InsufficientData(true, 0, 1))
+	assert.False(t, sr.InsufficientData(false, 0, 0))
+	assert.Equal(t, []bool{true, true}, sr.Upper[1])
+	assert.False(t, exp.SLOs())
+
+	// power requirements
+	in.SLOs = nil
+	task.(*assessTask).With.SampleSize = &SampleSize{Power: float64Pointer(0.8)}
+	assert.NoError(t, task.Run(context.Background(), exp))
+	sr = in.SLOsSatisfied
+	// the error rate of version 0 is not known with enough precision
+	assert.Equal(t, []bool{true, false}, sr.InsufficientUpper[0])
+	// version 0 latency is far enough from the limit; version 1 latency is at the limit
+	assert.Equal(t, []bool{false, true}, sr.InsufficientUpper[1])
+
+	// invalid requirements
+	task.(*assessTask).With.SampleSize = &SampleSize{
+		Min:        map[string]int{"a/b": -1},
+		Power:      float64Pointer(1),
+		Confidence: float64Pointer(0),
+	}
+	assert.Error(t, task.ValidateInputs())
+}
+
+func TestRequiredSampleSize(t *testing.T) {
+	// (1.645 + 0.842)^2 * 4 / 1 ~ 24.7
+	s := &sampleStats{n: 10, mean: 9, variance: 4}
+	assert.InDelta(t, 24.73, requiredSampleSize(s, WelchTest, 10, 0.95, 0.8), 0.01)
+	assert.True(t, math.IsInf(requiredSampleSize(s, WelchTest, 9, 0.95, 0.8), 1))
+	// variance at the limit is used for rates with no events
+	s = &sampleStats{n: 3, mean: 0, variance: 0}
+	assert.InDelta(t, 0.99*0.01*2.487*2.487/0.0001, requiredSampleSize(s, TwoProportionTest, 0.01, 0.95, 0.8), 1)
+	assert.Equal(t, 0.0, requiredSampleSize(s, WelchTest, 0.01, 0.95, 0.8))
+}
@@ -0,0 +1,182 @@
+package base
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// jsonSchemaDraft is the JSON Schema version used by the experiment schema
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+	// taskDefinitionPrefix prefixes the schema definitions of individual tasks
+	taskDefinitionPrefix = "task."
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// ExperimentSchema returns the JSON Schema of experiment specs
+// The schema is generated from the inputs of the run task, parallel blocks, and all registered tasks
+func ExperimentSchema() ([]byte, error) {
+	definitions := map[string]interface{}{}
+	tasks := map[string]reflect.Type{
+		RunTaskName:      reflect.TypeOf(runTask{}),
+		ParallelTaskName: reflect.TypeOf(parallelTask{}),
+	}
+	taskRegistryMutex.RLock()
+	for name, factory := range taskRegistry {
+		tasks[name] = reflect.TypeOf(factory())
+	}
+	taskRegistryMutex.RUnlock()
+
+	names := make([]string, 0, len(tasks))
+	for name := range tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	anyOf := []interface{}{}
+	for _, name := range names {
+		s := typeSchema(tasks[name], map[reflect.Type]bool{})
+		props := s["properties"].(map[string]interface{})
+		switch name {
+		case RunTaskName:
+			s["required"] = []string{"run"}
+		case ParallelTaskName:
+			s["required"] = []string{"parallel"}
+		default:
+			props["task"] = map[string]interface{}{"const": name}
+			s["required"] = []string{"task"}
+		}
+		definitions[taskDefinitionPrefix+name] = s
+		anyOf = append(anyOf, map[string]interface{}{"$ref": "#/definitions/" + taskDefinitionPrefix + name})
+	}
+	definitions["task"] = map[string]interface{}{"anyOf": anyOf}
+	definitions["spec"] = map[string]interface{}{
+		"type":  "array",
+		"items": map[string]interface{}{"$ref": "#/definitions/task"},
+	}
+
+	schema := map[string]interface{}{
+		"$schema": jsonSchemaDraft,
+		"title":   "Iter8 experiment",
+		"type":    "object",
+		"properties": map[string]interface{}{
+			"spec":   map[string]interface{}{"$ref": "#/definitions/spec"},
+			"result": map[string]interface{}{"type": "object"},
+		},
+		"required":             []string{"spec"},
+		"additionalProperties": false,
+		"definitions":          definitions,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema returns the JSON Schema for values that unmarshal into type t
+// seen tracks the struct types being expanded, which guards against recursive types
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(ExperimentSpec{}) {
+		return map[string]interface{}{"$ref": "#/definitions/spec"}
+	}
+	if hasCustomUnmarshaler(t) {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]interface{}{}
+		for name, f := range jsonFields(t) {
+			props[name] = typeSchema(f.Type, seen)
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem(), seen),
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), seen),
+		}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// hasCustomUnmarshaler returns true if values of type t unmarshal themselves
+func hasCustomUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+	return pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// jsonFields returns the fields of struct type t keyed by their JSON names
+// Fields of embedded structs are promoted, as they are by encoding/json
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	promoted := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && len(name) == 0 && ft.Kind() == reflect.Struct {
+			for n, pf := range jsonFields(ft) {
+				promoted[n] = pf
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	// fields of the outer struct take precedence over promoted fields
+	for n, pf := range promoted {
+		if _, ok := fields[n]; !ok {
+			fields[n] = pf
+		}
+	}
+	return fields
+}
@@ -0,0 +1,227 @@
+package base
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// Classification is the outcome of comparing a metric of a candidate version with the baseline version
+type Classification string
+
+// Direction identifies the changes in a metric that count against a candidate version
+type Direction string
+
+const (
+	// PassClassification indicates that the metric of the candidate is close to the baseline
+	PassClassification Classification = "pass"
+	// HighClassification indicates that the metric of the candidate is higher than the baseline
+	HighClassification Classification = "high"
+	// LowClassification indicates that the metric of the candidate is lower than the baseline
+	LowClassification Classification = "low"
+	// NoDataClassification indicates that the metric is not available for the candidate or the baseline
+	NoDataClassification Classification = "nodata"
+
+	// IncreaseDirection indicates that only an increase in the metric counts against the candidate
+	IncreaseDirection Direction = "increase"
+	// DecreaseDirection indicates that only a decrease in the metric counts against the candidate
+	DecreaseDirection Direction = "decrease"
+	// EitherDirection indicates that any change in the metric counts against the candidate
+	EitherDirection Direction = "either"
+
+	// defaultScoreTolerance is the default relative difference from the baseline that is classified as pass
+	defaultScoreTolerance = 0.1
+)
+
+// CanaryScore configures the scoring of candidate versions from weighted comparisons of metrics with a baseline
+//
+// Each metric of a candidate is classified as pass, high or low.
+// A metric is classified as pass if it is within the tolerance of the baseline,
+// or if the metric supports significance tests and the difference is not significant.
+// The score of a candidate is the percentage of the total weight of metrics that do not count against it;
+// metrics with no data are left out.
+type CanaryScore struct {
+	// Baseline is the index of the version that candidate versions are compared against
+	// Default value is 0
+	Baseline int `json:"baseline,omitempty" yaml:"baseline,omitempty"`
+
+	// Confidence is the confidence level required for a difference to be significant
+	// Default value is 0.95
+	Confidence *float64 `json:"confidence,omitempty" yaml:"confidence,omitempty"`
+
+	// Metrics are the metrics that are compared
+	Metrics []ScoreMetric `json:"metrics" yaml:"metrics"`
+}
+
+// ScoreMetric is a metric that contributes to the canary score
+type ScoreMetric struct {
+	// Metric is the name of the metric
+	Metric string `json:"metric" yaml:"metric"`
+
+	// Weight is the contribution of the metric to the score
+	// Default value is 1
+	Weight *float64 `json:"weight,omitempty" yaml:"weight,omitempty"`
+
+	// Direction identifies the changes that count against a candidate; increase, decrease, or either
+	// Default value is either
+	Direction Direction `json:"direction,omitempty" yaml:"direction,omitempty"`
+
+	// Tolerance is the relative difference from the baseline that is classified as pass
+	// Default value is 0.1, which allows the metric to differ from the baseline by 10%
+	Tolerance *float64 `json:"tolerance,omitempty" yaml:"tolerance,omitempty"`
+}
+
+// CanaryScores are the scores of versions
+type CanaryScores struct {
+	// Baseline is the index of the version that other versions are compared against
+	Baseline int `json:"baseline" yaml:"baseline"`
+
+	// Metrics are the metrics that are compared
+	Metrics []string `json:"metrics" yaml:"metrics"`
+
+	// Classifications[i][j] is the classification of metric i for version j
+	// Classifications of the baseline are empty
+	Classifications [][]Classification `json:"classifications" yaml:"classifications"`
+
+	// Scores[j] is the score of version j between 0 and 100
+	// Scores[j] is nil for the baseline and for versions with no data
+	Scores []*float64 `json:"scores" yaml:"scores"`
+}
+
+// Lowest returns the lowest score among candidate versions
+// nil is returned if any candidate version has no score
+func (cs *CanaryScores) Lowest() *float64 {
+	var lowest *float64
+	for j, s := range cs.Scores {
+		if j == cs.Baseline {
+			continue
+		}
+		if s == nil {
+			return nil
+		}
+		if lowest == nil || *s < *lowest {
+			lowest = float64Pointer(*s)
+		}
+	}
+	return lowest
+}
+
+// validate checks the canary score configuration located at the given path
+func (cs *CanaryScore) validate(errs *inputErrors, path string) {
+	if cs.Baseline < 0 {
+		errs.add(joinPath(path, "baseline"), "baseline must be a version index")
+	}
+	if c := cs.Confidence; c != nil && (*c <= 0 || *c >= 1) {
+		errs.add(joinPath(path, "confidence"), "confidence must be between 0 and 1")
+	}
+	if len(cs.Metrics) == 0 {
+		errs.add(joinPath(path, "metrics"), "at least one metric is required")
+	}
+	for i, m := range cs.Metrics {
+		mp := fmt.Sprintf("%v.metrics[%v]", path, i)
+		if len(m.Metric) == 0 {
+			errs.add(joinPath(mp, "metric"), "metric is required")
+		}
+		if m.Weight != nil && *m.Weight < 0 {
+			errs.add(joinPath(mp, "weight"), "weight cannot be negative")
+		}
+		if len(m.Direction) > 0 && m.Direction != IncreaseDirection && m.Direction != DecreaseDirection && m.Direction != EitherDirection {
+			errs.add(joinPath(mp, "direction"), "direction must be increase, decrease, or either")
+		}
+		if m.Tolerance != nil && *m.Tolerance < 0 {
+			errs.add(joinPath(mp, "tolerance"), "tolerance cannot be negative")
+		}
+	}
+}
+
+// evaluateScore classifies the metrics of each candidate version and computes their scores
+func evaluateScore(exp *Experiment, cs *CanaryScore) (*CanaryScores, error) {
+	in := exp.Result.Insights
+	if cs.Baseline >= in.NumVersions {
+		e := fmt.Errorf("baseline version %v is out of range; experiment has %v versions", cs.Baseline, in.NumVersions)
+		log.Logger.Error(e)
+		return nil, e
+	}
+	confidence := defaultRewardConfidence
+	if cs.Confidence != nil {
+		confidence = *cs.Confidence
+	}
+
+	scores := &CanaryScores{
+		Baseline:        cs.Baseline,
+		Metrics:         make([]string, len(cs.Metrics)),
+		Classifications: make([][]Classification, len(cs.Metrics)),
+		Scores:          make([]*float64, in.NumVersions),
+	}
+	// total weight, and weight of metrics that do not count against each version
+	total := make([]float64, in.NumVersions)
+	passed := make([]float64, in.NumVersions)
+	for i, m := range cs.Metrics {
+		scores.Metrics[i] = m.Metric
+		scores.Classifications[i] = make([]Classification, in.NumVersions)
+		weight := 1.0
+		if m.Weight != nil {
+			weight = *m.Weight
+		}
+		for j := 0; j < in.NumVersions; j++ {
+			if j == cs.Baseline {
+				continue
+			}
+			c := classify(in, m, cs.Baseline, j, confidence)
+			scores.Classifications[i][j] = c
+			if c == NoDataClassification {
+				continue
+			}
+			total[j] += weight
+			if !counts(c, m.Direction) {
+				passed[j] += weight
+			}
+		}
+	}
+	for j := 0; j < in.NumVersions; j++ {
+		if j != cs.Baseline && total[j] > 0 {
+			scores.Scores[j] = float64Pointer(100 * passed[j] / total[j])
+		}
+	}
+	return scores, nil
+}
+
+// classify compares the metric of version j with the baseline
+func classify(in *Insights, m ScoreMetric, baseline int, j int, confidence float64) Classification {
+	b := in.ScalarMetricValue(baseline, m.Metric)
+	v := in.ScalarMetricValue(j, m.Metric)
+	if b == nil || v == nil {
+		return NoDataClassification
+	}
+	tolerance := defaultScoreTolerance
+	if m.Tolerance != nil {
+		tolerance = *m.Tolerance
+	}
+	diff := *v - *b
+	if math.Abs(diff) <= tolerance*math.Abs(*b) {
+		return PassClassification
+	}
+	// differences that are not significant pass
+	sb, test := in.getSampleStats(baseline, m.Metric)
+	sv, _ := in.getSampleStats(j, m.Metric)
+	if sb != nil && sv != nil && pValue(sv, sb, test) > 1-confidence {
+		return PassClassification
+	}
+	if diff > 0 {
+		return HighClassification
+	}
+	return LowClassification
+}
+
+// counts returns true if the classification counts against the candidate, given the direction of the metric
+func counts(c Classification, d Direction) bool {
+	switch c {
+	case HighClassification:
+		return d != DecreaseDirection
+	case LowClassification:
+		return d != IncreaseDirection
+	default:
+		return false
+	}
+}
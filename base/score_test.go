@@ -0,0 +1,96 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCanaryScore(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	exp := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- task: assess
+  with:
+    score:
+      metrics:
+      - metric: a/latency
+        weight: 2
+        direction: increase
+      - metric: a/conversion
+        direction: decrease
+      - metric: a/sample/mean
+      - metric: a/missing
+`), exp)
+	assert.NoError(t, err)
+	assert.NoError(t, exp.Spec[0].ValidateInputs())
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(3)
+	in := exp.Result.Insights
+	for i, v := range [][]float64{{100, 0.1}, {105, 0.05}, {150, 0.2}} {
+		assert.NoError(t, in.updateMetric("a/latency", MetricMeta{Type: GaugeMetricType}, i, v[0]))
+		assert.NoError(t, in.updateMetric("a/conversion", MetricMeta{Type: GaugeMetricType}, i, v[1]))
+	}
+	// overlapping samples are not significantly different
+	assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 0, []float64{10, 30, 5, 25, 15}))
+	assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 1, []float64{12, 28, 6, 24, 16}))
+	assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 2, []float64{100, 101, 99, 100, 100}))
+
+	assert.NoError(t, exp.Spec[0].Run(context.Background(), exp))
+	cs := in.CanaryScores
+	assert.Equal(t, []Classification{"", PassClassification, HighClassification}, cs.Classifications[0])
+	assert.Equal(t, []Classification{"", LowClassification, HighClassification}, cs.Classifications[1])
+	assert.Equal(t, []Classification{"", PassClassification, HighClassification}, cs.Classifications[2])
+	assert.Equal(t, []Classification{"", NoDataClassification, NoDataClassification}, cs.Classifications[3])
+	assert.Nil(t, cs.Scores[0])
+	// a lower conversion counts against version 1
+	assert.InDelta(t, 75, *cs.Scores[1], 1e-9)
+	// a higher conversion does not count against version 2
+	assert.InDelta(t, 25, *cs.Scores[2], 1e-9)
+	assert.InDelta(t, 25, *cs.Lowest(), 1e-9)
+
+	// scores can be used in conditions
+	task := &runTask{TaskMeta: TaskMeta{Run: StringPointer("echo hello"), If: StringPointer("score(1) >= 75 && score(2) < 50")}}
+	ok, err := evaluateIf(task, exp)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// the baseline has no score, so comparisons with its score are false
+	task.If = StringPointer("score(0) > 0 || score(0) <= 0")
+	ok, err = evaluateIf(task, exp)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCanaryScoreInputs(t *testing.T) {
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Score: &CanaryScore{
+				Baseline:   -1,
+				Confidence: float64Pointer(2),
+				Metrics: []ScoreMetric{{
+					Weight:    float64Pointer(-1),
+					Direction: "up",
+					Tolerance: float64Pointer(-0.1),
+				}},
+			},
+		},
+	}
+	err := task.ValidateInputs()
+	var ie inputErrors
+	assert.ErrorAs(t, err, &ie)
+	assert.Equal(t, 6, len(ie))
+
+	// baseline must be a version of the experiment
+	task.With.Score = &CanaryScore{Baseline: 2, Metrics: []ScoreMetric{{Metric: "a/b"}}}
+	exp := &Experiment{Spec: []Task{task}}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	assert.Error(t, task.Run(context.Background(), exp))
+}
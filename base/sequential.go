@@ -0,0 +1,286 @@
+package base
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+)
+
+// Decision is the outcome of sequential testing
+type Decision string
+
+const (
+	// PromoteDecision indicates that a candidate version is better than the baseline
+	PromoteDecision Decision = "promote"
+	// RollbackDecision indicates that every candidate version is worse than the baseline
+	RollbackDecision Decision = "rollback"
+	// ContinueDecision indicates that there is not enough evidence yet
+	ContinueDecision Decision = "continue"
+
+	// defaultSequentialAlpha is the default significance level of sequential tests
+	defaultSequentialAlpha = 0.05
+)
+
+// SequentialTest configures sequential testing of reward metrics across experiment loops
+//
+// Candidate versions are compared with the baseline version using always-valid p-values,
+// computed with the mixture sequential probability ratio test (mSPRT).
+// Evidence accumulates across loops, and the p-values remain valid no matter how often they are checked,
+// so that the experiment can stop as soon as the outcome is settled.
+//
+// Sample, summary and histogram metrics keep their observations across loops, and are used as is;
+// this is the case for A/B/n metrics, which are replaced by their cumulative values in every loop.
+// Other reward metrics observed in a loop are treated as a new batch of observations;
+// this is the case for metrics collected by the http and grpc tasks, which only cover the loop in which they run.
+// Experiments must reuse their result across loops for evidence to accumulate.
+type SequentialTest struct {
+	// Baseline is the index of the version that candidate versions are compared against
+	// Default value is 0
+	Baseline int `json:"baseline,omitempty" yaml:"baseline,omitempty"`
+
+	// Alpha is the significance level of the tests; it bounds the chance of a wrong decision
+	// Default value is 0.05
+	Alpha *float64 `json:"alpha,omitempty" yaml:"alpha,omitempty"`
+
+	// Effect is the typical size of the difference between versions, in the units of the reward metric
+	// It tunes the tests to detect differences of this size quickly
+	// By default, it is the standard deviation of the observations
+	Effect *float64 `json:"effect,omitempty" yaml:"effect,omitempty"`
+}
+
+// SequentialEvidence is the evidence accumulated by sequential tests across experiment loops
+type SequentialEvidence struct {
+	// Loop is the latest experiment loop whose observations were accumulated
+	Loop int `json:"loop" yaml:"loop"`
+
+	// Rewards is the evidence for each reward metric
+	Rewards map[string]*RewardEvidence `json:"rewards,omitempty" yaml:"rewards,omitempty"`
+
+	// Winner is the index of the version to promote; it is set if the decision is to promote
+	Winner *int `json:"winner,omitempty" yaml:"winner,omitempty"`
+}
+
+// RewardEvidence is the evidence accumulated for a reward metric
+type RewardEvidence struct {
+	// Test is the kind of observations of the reward metric
+	Test SignificanceTest `json:"test" yaml:"test"`
+
+	// Observations[j] summarizes the accumulated observations for version j
+	Observations []*Observations `json:"observations" yaml:"observations"`
+
+	// PValues[j] is the always-valid p-value of the difference between version j and the baseline
+	// PValues[j] is nil for the baseline and for versions with insufficient data
+	PValues []*float64 `json:"pValues" yaml:"pValues"`
+}
+
+// Observations summarizes a set of observations
+type Observations struct {
+	// Count is the number of observations
+	Count float64 `json:"count" yaml:"count"`
+	// Sum of the observations
+	Sum float64 `json:"sum" yaml:"sum"`
+	// SumSquares is the sum of the squares of the observations
+	SumSquares float64 `json:"sumSquares" yaml:"sumSquares"`
+}
+
+// add adds a batch of observations
+func (o *Observations) add(s *sampleStats, test SignificanceTest) {
+	o.Count += s.n
+	o.Sum += s.n * s.mean
+	if test == TwoProportionTest {
+		// observations are 0 or 1
+		o.SumSquares += s.n * s.mean
+	} else {
+		o.SumSquares += s.variance*(s.n-1) + s.n*s.mean*s.mean
+	}
+}
+
+// stats returns the summary statistics of the observations
+func (o *Observations) stats(test SignificanceTest) *sampleStats {
+	mean := o.Sum / o.Count
+	s := &sampleStats{n: o.Count, mean: mean}
+	if test == TwoProportionTest {
+		s.variance = mean * (1 - mean)
+	} else {
+		s.variance = math.Max(0, (o.SumSquares-o.Count*mean*mean)/(o.Count-1))
+	}
+	return s
+}
+
+// validate checks the sequential test configuration located at the given path
+func (st *SequentialTest) validate(errs *inputErrors, path string) {
+	if st.Baseline < 0 {
+		errs.add(joinPath(path, "baseline"), "baseline must be a version index")
+	}
+	if st.Alpha != nil && (*st.Alpha <= 0 || *st.Alpha >= 1) {
+		errs.add(joinPath(path, "alpha"), "alpha must be between 0 and 1")
+	}
+	if st.Effect != nil && *st.Effect <= 0 {
+		errs.add(joinPath(path, "effect"), "effect must be positive")
+	}
+}
+
+// evaluateSequential accumulates the observations of the reward metrics in the current loop,
+// updates the always-valid p-values, and decides whether to promote, rollback, or continue
+func evaluateSequential(exp *Experiment, st *SequentialTest, rewards *Rewards) error {
+	in := exp.Result.Insights
+	if st.Baseline >= in.NumVersions {
+		e := fmt.Errorf("baseline version %v is out of range; experiment has %v versions", st.Baseline, in.NumVersions)
+		log.Logger.Error(e)
+		return e
+	}
+	alpha := defaultSequentialAlpha
+	if st.Alpha != nil {
+		alpha = *st.Alpha
+	}
+
+	if in.SequentialEvidence == nil {
+		in.SequentialEvidence = &SequentialEvidence{
+			Rewards: map[string]*RewardEvidence{},
+		}
+	}
+	ev := in.SequentialEvidence
+	// observations are accumulated once per loop
+	accumulate := ev.Loop < exp.Result.NumLoops
+	ev.Loop = exp.Result.NumLoops
+
+	// better[j] and worse[j] are true if version j is significantly better or worse than the baseline for some reward
+	better := make([]bool, in.NumVersions)
+	worse := make([]bool, in.NumVersions)
+	// pBetter[j] is the smallest p-value among rewards for which version j is significantly better
+	pBetter := make([]float64, in.NumVersions)
+
+	check := func(reward string, max bool) {
+		re := ev.Rewards[reward]
+		if accumulate {
+			re = accumulateReward(in, reward, re)
+			ev.Rewards[reward] = re
+		}
+		if re == nil {
+			return
+		}
+		base := re.Observations[st.Baseline]
+		for j := 0; j < in.NumVersions; j++ {
+			o := re.Observations[j]
+			if j == st.Baseline || o == nil || base == nil || o.Count < 2 || base.Count < 2 {
+				continue
+			}
+			a, b := o.stats(re.Test), base.stats(re.Test)
+			p := 1 / mixtureLikelihoodRatio(a, b, st.Effect)
+			// always-valid p-values never increase
+			if re.PValues[j] != nil {
+				p = math.Min(p, *re.PValues[j])
+			}
+			p = math.Min(p, 1)
+			re.PValues[j] = float64Pointer(p)
+			if p >= alpha || a.mean == b.mean {
+				continue
+			}
+			if (a.mean > b.mean) == max {
+				if !better[j] || p < pBetter[j] {
+					pBetter[j] = p
+				}
+				better[j] = true
+			} else {
+				worse[j] = true
+			}
+		}
+	}
+	for _, reward := range rewards.Max {
+		check(reward, true)
+	}
+	for _, reward := range rewards.Min {
+		check(reward, false)
+	}
+
+	// decisions are final once the outcome is settled
+	if exp.Result.Decision == PromoteDecision || exp.Result.Decision == RollbackDecision {
+		return nil
+	}
+
+	winner := -1
+	allWorse := in.NumVersions > 1
+	for j := 0; j < in.NumVersions; j++ {
+		if j == st.Baseline {
+			continue
+		}
+		allWorse = allWorse && worse[j]
+		if better[j] && !worse[j] && (winner == -1 || pBetter[j] < pBetter[winner]) {
+			winner = j
+		}
+	}
+	switch {
+	case winner >= 0:
+		exp.Result.Decision = PromoteDecision
+		ev.Winner = intPointer(winner)
+	case allWorse:
+		exp.Result.Decision = RollbackDecision
+	default:
+		exp.Result.Decision = ContinueDecision
+	}
+	log.Logger.Infof("sequential test decision after loop %v: %v", exp.Result.NumLoops, exp.Result.Decision)
+	return nil
+}
+
+// accumulateReward adds the observations of the reward metric in the current loop to the evidence
+// nil is returned if the reward metric does not support significance tests
+func accumulateReward(in *Insights, reward string, re *RewardEvidence) *RewardEvidence {
+	for j := 0; j < in.NumVersions; j++ {
+		s, test := in.getSampleStats(j, reward)
+		if len(test) == 0 {
+			log.Logger.Warnf("reward %v does not support sequential tests", reward)
+			return re
+		}
+		if re == nil {
+			re = &RewardEvidence{
+				Test:         test,
+				Observations: make([]*Observations, in.NumVersions),
+				PValues:      make([]*float64, in.NumVersions),
+			}
+		}
+		if s == nil {
+			continue
+		}
+		// sample, summary and histogram metrics, and counters, already hold the observations of earlier loops
+		if re.Observations[j] == nil || test == TwoProportionTest || in.isCumulativeAggregate(reward) {
+			re.Observations[j] = &Observations{}
+		}
+		re.Observations[j].add(s, test)
+	}
+	return re
+}
+
+// mixtureLikelihoodRatio is the mSPRT statistic for the difference between the means of a and b,
+// using a normal mixture over the difference with standard deviation effect
+func mixtureLikelihoodRatio(a *sampleStats, b *sampleStats, effect *float64) float64 {
+	// variance of the estimated difference
+	v := a.variance/a.n + b.variance/b.n
+	// variance of the mixture
+	var tau2 float64
+	if effect != nil {
+		tau2 = *effect * *effect
+	} else {
+		tau2 = (a.variance + b.variance) / 2
+	}
+	diff := a.mean - b.mean
+	if v == 0 || tau2 == 0 {
+		// neither version has any variation
+		if diff == 0 {
+			return 1
+		}
+		return math.Inf(1)
+	}
+	return math.Sqrt(v/(v+tau2)) * math.Exp(tau2*diff*diff/(2*v*(v+tau2)))
+}
+
+// isCumulativeAggregate returns true if the metric is an aggregation of a sample, summary or histogram metric
+func (in *Insights) isCumulativeAggregate(m string) bool {
+	s := strings.Split(m, "/")
+	if len(s) != 3 {
+		return false
+	}
+	mm, ok := in.MetricsInfo[s[0]+"/"+s[1]]
+	return ok && (mm.Type == SampleMetricType || mm.Type == SummaryMetricType || mm.Type == HistogramMetricType)
+}
@@ -0,0 +1,167 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base/summarymetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequentialTest(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Rewards: &Rewards{
+				Max: []string{"a/sample/mean"},
+			},
+			Sequential: &SequentialTest{},
+		},
+	}
+	assert.NoError(t, task.ValidateInputs())
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	in := exp.Result.Insights
+
+	// observe more samples in each loop
+	loop := func(baseline []float64, candidate []float64) {
+		exp.Result.NumLoops++
+		assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 0, baseline))
+		assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 1, candidate))
+		assert.NoError(t, task.Run(context.Background(), exp))
+	}
+
+	// the difference is not settled after a small batch
+	loop([]float64{10, 12, 8, 11}, []float64{11, 13, 9, 12})
+	assert.Equal(t, ContinueDecision, exp.Result.Decision)
+	assert.Equal(t, "continue", exp.Decision())
+	re := in.SequentialEvidence.Rewards["a/sample/mean"]
+	assert.Equal(t, WelchTest, re.Test)
+	assert.Equal(t, 4.0, re.Observations[1].Count)
+	assert.Nil(t, re.PValues[0])
+	p := *re.PValues[1]
+	assert.Greater(t, p, defaultSequentialAlpha)
+
+	// evidence is not accumulated twice in the same loop
+	assert.NoError(t, task.Run(context.Background(), exp))
+	assert.Equal(t, 4.0, re.Observations[1].Count)
+
+	// evidence accumulates across loops
+	loop([]float64{10, 12, 8, 11, 9, 10}, []float64{12, 14, 10, 13, 11, 12})
+	assert.Equal(t, 10.0, re.Observations[1].Count)
+	for k := 0; k < 5 && exp.Result.Decision == ContinueDecision; k++ {
+		loop([]float64{10, 12, 8, 11, 9, 10}, []float64{12, 14, 10, 13, 11, 12})
+		assert.LessOrEqual(t, *re.PValues[1], p)
+		p = *re.PValues[1]
+	}
+	assert.Equal(t, PromoteDecision, exp.Result.Decision)
+	assert.Equal(t, 1, *in.SequentialEvidence.Winner)
+	assert.Less(t, p, defaultSequentialAlpha)
+
+	// the decision is final
+	loop([]float64{12, 14, 10, 13}, []float64{10, 12, 8, 11})
+	assert.Equal(t, PromoteDecision, exp.Result.Decision)
+}
+
+func TestSequentialTestABn(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Rewards: &Rewards{
+				Max: []string{"abn/sales/mean"},
+			},
+			Sequential: &SequentialTest{},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	in := exp.Result.Insights
+
+	// A/B/n metrics are cumulative, and are replaced in each loop
+	sales := []*summarymetrics.SummaryMetric{summarymetrics.EmptySummaryMetric(), summarymetrics.EmptySummaryMetric()}
+	loop := func(baseline []float64, candidate []float64) {
+		exp.Result.NumLoops++
+		for i, vals := range [][]float64{baseline, candidate} {
+			for _, v := range vals {
+				sales[i].Add(v)
+			}
+			assert.NoError(t, in.updateMetric("abn/sales", MetricMeta{Type: SummaryMetricType}, i, sales[i]))
+		}
+		assert.NoError(t, task.Run(context.Background(), exp))
+	}
+
+	loop([]float64{10, 12, 8, 11}, []float64{11, 13, 9, 12})
+	re := in.SequentialEvidence.Rewards["abn/sales/mean"]
+	assert.Equal(t, 4.0, re.Observations[0].Count)
+	assert.Equal(t, 4.0, re.Observations[1].Count)
+	p := *re.PValues[1]
+
+	// observations are not counted again in later loops
+	loop([]float64{9}, []float64{10})
+	assert.Equal(t, 5.0, re.Observations[0].Count)
+	assert.Equal(t, 5.0, re.Observations[1].Count)
+	assert.Equal(t, sales[1].Sum(), re.Observations[1].Sum)
+	assert.LessOrEqual(t, *re.PValues[1], p)
+	loop(nil, nil)
+	assert.Equal(t, 5.0, re.Observations[1].Count)
+	assert.Equal(t, ContinueDecision, exp.Result.Decision)
+}
+
+func TestSequentialRollback(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Rewards: &Rewards{
+				Min: []string{"http/error-rate"},
+			},
+			Sequential: &SequentialTest{Baseline: 1},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	exp.Result.NumLoops = 1
+	_ = exp.Result.initInsightsWithNumVersions(3)
+	in := exp.Result.Insights
+
+	// both candidates have more errors than the baseline
+	for i, v := range [][]float64{{1000, 90, 0.09}, {1000, 20, 0.02}, {1000, 100, 0.1}} {
+		assert.NoError(t, in.updateMetric("http/request-count", MetricMeta{Type: CounterMetricType}, i, v[0]))
+		assert.NoError(t, in.updateMetric("http/error-count", MetricMeta{Type: CounterMetricType}, i, v[1]))
+		assert.NoError(t, in.updateMetric("http/error-rate", MetricMeta{Type: GaugeMetricType}, i, v[2]))
+	}
+	assert.NoError(t, task.Run(context.Background(), exp))
+	assert.Equal(t, RollbackDecision, exp.Result.Decision)
+	assert.Nil(t, in.SequentialEvidence.Winner)
+	assert.Equal(t, TwoProportionTest, in.SequentialEvidence.Rewards["http/error-rate"].Test)
+
+	// baseline must be a version of the experiment
+	exp.Result.Decision = ""
+	in.Rewards = nil
+	task.With.Sequential.Baseline = 3
+	assert.Error(t, task.Run(context.Background(), exp))
+
+	// invalid configuration
+	task.With.Sequential = &SequentialTest{Alpha: float64Pointer(5)}
+	assert.Error(t, task.ValidateInputs())
+	task.With.Rewards = nil
+	task.With.Sequential = &SequentialTest{}
+	assert.Error(t, task.ValidateInputs())
+}
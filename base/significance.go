@@ -0,0 +1,350 @@
+package base
+
+import (
+	"math"
+	"strings"
+
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/montanaflynn/stats"
+)
+
+// SignificanceTest identifies the statistical test used to compare versions
+type SignificanceTest string
+
+const (
+	// WelchTest is Welch's t-test for the difference between the means of two versions
+	// It is used for the mean of sample, summary and histogram metrics, and for latency-mean style metrics
+	WelchTest SignificanceTest = "welch"
+	// TwoProportionTest is the two-proportion z-test for the difference between the rates of two versions
+	// It is used for rate metrics such as http/error-rate
+	TwoProportionTest SignificanceTest = "two-proportion"
+
+	// defaultRewardConfidence is the default confidence level required to declare a winner
+	defaultRewardConfidence = 0.95
+
+	// metric name suffixes used to find the data behind rates and means
+	rateSuffix         = "-rate"
+	countSuffix        = "-count"
+	meanSuffix         = "-mean"
+	stdDevSuffix       = "-stddev"
+	requestCountMetric = "request-count"
+)
+
+// sampleStats summarizes the observations of a metric for a version
+type sampleStats struct {
+	// n is the number of observations
+	n float64
+	// mean of the observations; for rates, this is the proportion of events
+	mean float64
+	// variance of the observations
+	variance float64
+}
+
+// getSampleStats returns the summary of the observations of a reward metric for version i
+// along with the test used to compare versions
+// nil is returned if the metric does not support significance tests or if version i has insufficient data
+//
+// The following metrics support significance tests:
+//
+//	backend/metric/mean, where backend/metric is a sample, summary or histogram metric (Welch's t-test)
+//	backend/name-mean, if backend/name-stddev and backend/request-count are available (Welch's t-test)
+//	backend/name-rate, if backend/name-count and backend/request-count are available (two-proportion test)
+func (in *Insights) getSampleStats(i int, m string) (*sampleStats, SignificanceTest) {
+	s := strings.Split(m, "/")
+	if len(s) == 3 {
+		if AggregationType(s[2]) != MeanAggregator {
+			return nil, ""
+		}
+		baseMetric := s[0] + "/" + s[1]
+		mm, ok := in.MetricsInfo[baseMetric]
+		if !ok {
+			return nil, ""
+		}
+		switch mm.Type {
+		case SampleMetricType:
+			if i >= len(in.NonHistMetricValues) {
+				return nil, WelchTest
+			}
+			vals := in.NonHistMetricValues[i][baseMetric]
+			if len(vals) < 2 {
+				return nil, WelchTest
+			}
+			mean, _ := stats.Mean(vals)
+			variance, _ := stats.SampleVariance(vals)
+			return &sampleStats{n: float64(len(vals)), mean: mean, variance: variance}, WelchTest
+		case SummaryMetricType:
+			if i >= len(in.SummaryMetricValues) {
+				return nil, WelchTest
+			}
+			sm, ok := in.SummaryMetricValues[i][baseMetric]
+			if !ok || sm.Count() < 2 {
+				return nil, WelchTest
+			}
+			n := float64(sm.Count())
+			mean := sm.Sum() / n
+			return &sampleStats{n: n, mean: mean, variance: (sm.SumSquares() - n*mean*mean) / (n - 1)}, WelchTest
+		case HistogramMetricType:
+			if i >= len(in.HistMetricValues) {
+				return nil, WelchTest
+			}
+			bins := mergeHistBuckets(in.HistMetricValues[i][baseMetric])
+			n := histCount(bins)
+			if n < 2 {
+				return nil, WelchTest
+			}
+			mean, variance := histMoments(bins)
+			return &sampleStats{n: n, mean: mean, variance: variance}, WelchTest
+		}
+		return nil, ""
+	}
+	if len(s) != 2 {
+		return nil, ""
+	}
+
+	requests := s[0] + "/" + requestCountMetric
+	if strings.HasSuffix(s[1], rateSuffix) {
+		events := s[0] + "/" + strings.TrimSuffix(s[1], rateSuffix) + countSuffix
+		if !in.hasCounterOrGauge(events) || !in.hasCounterOrGauge(requests) {
+			return nil, ""
+		}
+		n := in.getCounterOrGaugeMetricFromValuesMap(i, requests)
+		x := in.getCounterOrGaugeMetricFromValuesMap(i, events)
+		if n == nil || x == nil || *n < 2 {
+			return nil, TwoProportionTest
+		}
+		p := *x / *n
+		return &sampleStats{n: *n, mean: p, variance: p * (1 - p)}, TwoProportionTest
+	}
+	if strings.HasSuffix(s[1], meanSuffix) {
+		stdDev := s[0] + "/" + strings.TrimSuffix(s[1], meanSuffix) + stdDevSuffix
+		if !in.hasCounterOrGauge(stdDev) || !in.hasCounterOrGauge(requests) {
+			return nil, ""
+		}
+		n := in.getCounterOrGaugeMetricFromValuesMap(i, requests)
+		if in.MetricsInfo[m].PerLoop {
+			// per-loop gauges describe the requests sent in the latest loop
+			n = in.latestMetricValue(i, requests)
+		}
+		mean := in.getCounterOrGaugeMetricFromValuesMap(i, m)
+		sd := in.getCounterOrGaugeMetricFromValuesMap(i, stdDev)
+		if n == nil || mean == nil || sd == nil || *n < 2 {
+			return nil, WelchTest
+		}
+		return &sampleStats{n: *n, mean: *mean, variance: *sd * *sd}, WelchTest
+	}
+	return nil, ""
+}
+
+// hasCounterOrGauge returns true if the metric is a known counter or gauge metric
+func (in *Insights) hasCounterOrGauge(m string) bool {
+	mm, ok := in.MetricsInfo[m]
+	return ok && (mm.Type == CounterMetricType || mm.Type == GaugeMetricType)
+}
+
+// getRewardStats compares the best version of a reward metric against every other version
+// The best version is declared the winner only if all comparisons are significant at the given confidence level
+// If the metric does not support significance tests, the best version is the winner and nil stats are returned
+func getRewardStats(in *Insights, reward string, best int, confidence float64) (int, *RewardStats) {
+	if best < 0 {
+		return best, nil
+	}
+	ss := make([]*sampleStats, in.NumVersions)
+	var test SignificanceTest
+	for j := 0; j < in.NumVersions; j++ {
+		ss[j], test = in.getSampleStats(j, reward)
+	}
+	if len(test) == 0 {
+		return best, nil
+	}
+
+	rs := &RewardStats{
+		Test:       test,
+		Confidence: confidence,
+		Intervals:  make([]*ConfidenceInterval, in.NumVersions),
+		PValues:    make([]*float64, in.NumVersions),
+	}
+	for j := 0; j < in.NumVersions; j++ {
+		if ss[j] != nil {
+			rs.Intervals[j] = confidenceInterval(ss[j], test, confidence)
+		}
+	}
+	if ss[best] == nil {
+		log.Logger.Warnf("insufficient data to test significance of reward %v for version %v", reward, best)
+		return -1, rs
+	}
+
+	winner := best
+	for j := 0; j < in.NumVersions; j++ {
+		if j == best {
+			continue
+		}
+		if ss[j] == nil {
+			// versions without a value are not compared, as when the best version is identified
+			if in.ScalarMetricValue(j, reward) != nil {
+				log.Logger.Warnf("insufficient data to test significance of reward %v for version %v", reward, j)
+				winner = -1
+			}
+			continue
+		}
+		p := pValue(ss[best], ss[j], test)
+		rs.PValues[j] = float64Pointer(p)
+		if p > 1-confidence {
+			winner = -1
+		}
+	}
+	return winner, rs
+}
+
+// confidenceInterval returns the confidence interval of the mean or rate of a version
+func confidenceInterval(s *sampleStats, test SignificanceTest, confidence float64) *ConfidenceInterval {
+	var q float64
+	if test == TwoProportionTest {
+		q = quantile(normalCDF, (1+confidence)/2)
+	} else {
+		q = quantile(func(x float64) float64 { return studentTCDF(x, s.n-1) }, (1+confidence)/2)
+	}
+	hw := q * math.Sqrt(s.variance/s.n)
+	ci := &ConfidenceInterval{
+		Lower: s.mean - hw,
+		Upper: s.mean + hw,
+	}
+	// rates are between 0 and 1
+	if test == TwoProportionTest {
+		ci.Lower = math.Max(ci.Lower, 0)
+		ci.Upper = math.Min(ci.Upper, 1)
+	}
+	return ci
+}
+
+// pValue returns the two-sided p-value of the test of the difference between two versions
+func pValue(a *sampleStats, b *sampleStats, test SignificanceTest) float64 {
+	diff := a.mean - b.mean
+	if test == TwoProportionTest {
+		// pooled proportion
+		p := (a.mean*a.n + b.mean*b.n) / (a.n + b.n)
+		se := math.Sqrt(p * (1 - p) * (1/a.n + 1/b.n))
+		if se == 0 {
+			return degeneratePValue(diff)
+		}
+		return 2 * (1 - normalCDF(math.Abs(diff)/se))
+	}
+
+	va := a.variance / a.n
+	vb := b.variance / b.n
+	se := math.Sqrt(va + vb)
+	if se == 0 {
+		return degeneratePValue(diff)
+	}
+	// Welch-Satterthwaite degrees of freedom
+	df := (va + vb) * (va + vb) / (va*va/(a.n-1) + vb*vb/(b.n-1))
+	return 2 * (1 - studentTCDF(math.Abs(diff)/se, df))
+}
+
+// degeneratePValue returns the p-value when neither version has any variation
+func degeneratePValue(diff float64) float64 {
+	if diff == 0 {
+		return 1
+	}
+	return 0
+}
+
+// normalCDF is the cumulative distribution function of the standard normal distribution
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// studentTCDF is the cumulative distribution function of Student's t distribution with df degrees of freedom
+func studentTCDF(t float64, df float64) float64 {
+	ib := regIncBeta(df/2, 0.5, df/(df+t*t))
+	if t >= 0 {
+		return 1 - 0.5*ib
+	}
+	return 0.5 * ib
+}
+
+// quantile inverts a continuous cumulative distribution function using bisection
+func quantile(cdf func(float64) float64, p float64) float64 {
+	lo, hi := -1.0, 1.0
+	for cdf(lo) > p {
+		lo *= 2
+	}
+	for cdf(hi) < p {
+		hi *= 2
+	}
+	for k := 0; k < 100; k++ {
+		mid := (lo + hi) / 2
+		if cdf(mid) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regIncBeta is the regularized incomplete beta function I_x(a, b)
+func regIncBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges quickly for x < (a+1)/(a+b+2)
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+// betaContinuedFraction evaluates the continued fraction for the incomplete beta function using Lentz's method
+func betaContinuedFraction(a float64, b float64, x float64) float64 {
+	const (
+		maxIterations = 300
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		// even step
+		num := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		// odd step
+		num = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + num*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + num/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,107 @@
+package base
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributions(t *testing.T) {
+	assert.InDelta(t, 0.975, normalCDF(1.959964), 1e-6)
+	assert.InDelta(t, 0.5, studentTCDF(0, 5), 1e-9)
+	assert.InDelta(t, 0.975, studentTCDF(2.228139, 10), 1e-6)
+	assert.InDelta(t, 0.025, studentTCDF(-2.228139, 10), 1e-6)
+	assert.InDelta(t, 12.7062, quantile(func(x float64) float64 { return studentTCDF(x, 1) }, 0.975), 1e-3)
+	assert.InDelta(t, 1.959964, quantile(normalCDF, 0.975), 1e-6)
+}
+
+func TestPValue(t *testing.T) {
+	// Welch's t-test
+	a := &sampleStats{n: 10, mean: 20, variance: 4}
+	b := &sampleStats{n: 12, mean: 22, variance: 9}
+	// t = -1.8650, df = 19.19
+	assert.InDelta(t, 0.0775, pValue(a, b, WelchTest), 1e-3)
+	assert.Equal(t, 1.0, pValue(a, a, WelchTest))
+
+	// two-proportion test
+	a = &sampleStats{n: 1000, mean: 0.05, variance: 0.05 * 0.95}
+	b = &sampleStats{n: 1000, mean: 0.08, variance: 0.08 * 0.92}
+	// z = -2.7206
+	assert.InDelta(t, 0.0065, pValue(a, b, TwoProportionTest), 1e-3)
+
+	// no variation
+	c := &sampleStats{n: 100, mean: 0, variance: 0}
+	d := &sampleStats{n: 100, mean: 1, variance: 0}
+	assert.Equal(t, 0.0, pValue(c, d, WelchTest))
+}
+
+func TestRewardSignificance(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	task := &assessTask{
+		TaskMeta: TaskMeta{
+			Task: StringPointer(AssessTaskName),
+		},
+		With: assessInputs{
+			Rewards: &Rewards{
+				Max: []string{"a/sample/mean", "a/gauge"},
+				Min: []string{"http/error-rate", "a/noisy/mean"},
+			},
+			SLOs: &SLOLimits{},
+		},
+	}
+	exp := &Experiment{
+		Spec: []Task{task},
+	}
+	exp.initResults(1)
+	_ = exp.Result.initInsightsWithNumVersions(2)
+	in := exp.Result.Insights
+
+	// clearly different samples
+	assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 0, []float64{10, 11, 9, 10, 10, 11, 9}))
+	assert.NoError(t, in.updateMetric("a/sample", MetricMeta{Type: SampleMetricType}, 1, []float64{20, 21, 19, 20, 20, 21, 19}))
+	// overlapping samples
+	assert.NoError(t, in.updateMetric("a/noisy", MetricMeta{Type: SampleMetricType}, 0, []float64{10, 30, 5, 25, 15}))
+	assert.NoError(t, in.updateMetric("a/noisy", MetricMeta{Type: SampleMetricType}, 1, []float64{12, 28, 6, 24, 16}))
+	// gauges do not support significance tests
+	assert.NoError(t, in.updateMetric("a/gauge", MetricMeta{Type: GaugeMetricType}, 0, 1.0))
+	assert.NoError(t, in.updateMetric("a/gauge", MetricMeta{Type: GaugeMetricType}, 1, 2.0))
+	// rates
+	for i, v := range [][]float64{{1000, 50, 0.05}, {1000, 80, 0.08}} {
+		assert.NoError(t, in.updateMetric("http/request-count", MetricMeta{Type: CounterMetricType}, i, v[0]))
+		assert.NoError(t, in.updateMetric("http/error-count", MetricMeta{Type: CounterMetricType}, i, v[1]))
+		assert.NoError(t, in.updateMetric("http/error-rate", MetricMeta{Type: GaugeMetricType}, i, v[2]))
+	}
+
+	err := task.Run(context.Background(), exp)
+	assert.NoError(t, err)
+
+	rw := in.RewardsWinners
+	assert.Equal(t, []int{1, 1}, rw.Max)
+	assert.Equal(t, WelchTest, rw.MaxStats[0].Test)
+	assert.Nil(t, rw.MaxStats[0].PValues[1])
+	assert.Less(t, *rw.MaxStats[0].PValues[0], 0.001)
+	assert.Less(t, rw.MaxStats[0].Intervals[1].Lower, 20.0)
+	assert.Greater(t, rw.MaxStats[0].Intervals[1].Upper, 20.0)
+	assert.Nil(t, rw.MaxStats[1])
+
+	// the difference in noisy samples is not significant
+	assert.Equal(t, []int{0, -1}, rw.Min)
+	assert.Equal(t, TwoProportionTest, rw.MinStats[0].Test)
+	assert.InDelta(t, 0.0065, *rw.MinStats[0].PValues[1], 1e-3)
+	assert.GreaterOrEqual(t, rw.MinStats[0].Intervals[0].Lower, 0.0)
+	assert.NotNil(t, rw.MinStats[1].PValues[1])
+
+	// the difference in rates is not significant at a higher confidence level
+	in.Rewards = nil
+	in.SLOs = nil
+	task.With.Rewards.Confidence = float64Pointer(0.999)
+	err = task.Run(context.Background(), exp)
+	assert.NoError(t, err)
+	assert.Equal(t, -1, in.RewardsWinners.Min[0])
+
+	// confidence must be a probability
+	task.With.Rewards.Confidence = float64Pointer(95)
+	assert.Error(t, task.ValidateInputs())
+}
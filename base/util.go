@@ -1,6 +1,7 @@
 package base
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"path/filepath"
@@ -64,10 +65,15 @@ func CompletePath(prefix string, suffix string) string {
 }
 
 // getTextTemplateFromURL gets template from URL
-func getTextTemplateFromURL(providerURL string) (*template.Template, error) {
+func getTextTemplateFromURL(ctx context.Context, providerURL string) (*template.Template, error) {
 	// fetch b from url
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, providerURL, nil)
+	if err != nil {
+		log.Logger.Error(err)
+		return nil, err
+	}
 	// #nosec
-	resp, err := http.Get(providerURL)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		log.Logger.Error(err)
 		return nil, err
@@ -0,0 +1,335 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/antonmedv/expr"
+	"github.com/itchyny/gojq"
+	log "github.com/iter8-tools/iter8/base/log"
+	"sigs.k8s.io/yaml"
+)
+
+// SpecProblem is a problem found while validating an experiment spec
+type SpecProblem struct {
+	// Path locates the problem within the experiment YAML; for example, spec[1].with.url
+	Path string `json:"path" yaml:"path"`
+	// Message describes the problem
+	Message string `json:"message" yaml:"message"`
+}
+
+// String returns the problem in the path: message format
+func (p SpecProblem) String() string {
+	if len(p.Path) == 0 {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// inputError is an invalid task input
+type inputError struct {
+	// path of the input relative to the task; for example, with.url
+	path string
+	// msg describes the problem with the input
+	msg string
+}
+
+// inputErrors collects all the invalid inputs of a task
+// ValidateInputs of built-in tasks return inputErrors so that every problem can be reported with its path
+type inputErrors []inputError
+
+// add records an invalid input
+func (e *inputErrors) add(path string, format string, a ...interface{}) {
+	*e = append(*e, inputError{
+		path: path,
+		msg:  fmt.Sprintf(format, a...),
+	})
+}
+
+// Error returns all the invalid inputs as a single message
+func (e inputErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, ie := range e {
+		msgs[i] = ie.path + ": " + ie.msg
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// errOrNil returns nil if no invalid inputs were recorded
+func (e inputErrors) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// joinPath appends elem to the given YAML path
+func joinPath(path string, elem string) string {
+	if len(path) == 0 {
+		return elem
+	}
+	return path + "." + elem
+}
+
+// Validate checks the inputs of every task in the experiment spec without running any of them
+// All problems found are returned; the spec is valid if there are none
+func (s ExperimentSpec) Validate() []SpecProblem {
+	return s.validate("spec")
+}
+
+// validate checks the tasks in a spec located at the given path
+func (s ExperimentSpec) validate(path string) []SpecProblem {
+	problems := []SpecProblem{}
+	for i, t := range s {
+		tp := fmt.Sprintf("%v[%v]", path, i)
+		tm := getTaskMeta(t)
+
+		if _, err := getTaskPolicy(tm); err != nil {
+			problems = append(problems, SpecProblem{Path: tp, Message: err.Error()})
+		}
+		if tm.If != nil {
+			if _, err := expr.Compile(*tm.If, conditionOptions(&Experiment{})...); err != nil {
+				problems = append(problems, SpecProblem{Path: joinPath(tp, "if"), Message: err.Error()})
+			}
+		}
+
+		if len(tm.Outputs) > 0 {
+			if _, ok := t.(outputTask); !ok {
+				problems = append(problems, SpecProblem{Path: joinPath(tp, "outputs"), Message: "task " + *getName(t) + " does not produce outputs"})
+			}
+			for _, name := range sortedStringKeys(tm.Outputs) {
+				if _, err := gojq.Parse(tm.Outputs[name]); err != nil {
+					problems = append(problems, SpecProblem{Path: joinPath(tp, "outputs."+name), Message: err.Error()})
+				}
+			}
+		}
+
+		if err := t.ValidateInputs(); err != nil {
+			var ie inputErrors
+			if errors.As(err, &ie) {
+				for _, e := range ie {
+					problems = append(problems, SpecProblem{Path: joinPath(tp, e.path), Message: e.msg})
+				}
+			} else {
+				problems = append(problems, SpecProblem{Path: tp, Message: err.Error()})
+			}
+		}
+
+		// tasks within a parallel block are validated individually
+		if pt, ok := t.(*parallelTask); ok {
+			problems = append(problems, pt.Parallel.validate(joinPath(tp, "parallel"))...)
+		}
+	}
+	return problems
+}
+
+// CheckExperimentYAML checks the structure of an experiment YAML document against the experiment schema
+// Unknown tasks, unknown fields, and values of the wrong type are reported along with their paths
+// Inputs of individual tasks are not validated; use ExperimentSpec.Validate for that
+func CheckExperimentYAML(b []byte) []SpecProblem {
+	jb, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to parse experiment YAML")
+		return []SpecProblem{{Message: err.Error()}}
+	}
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(jb))
+	d.UseNumber()
+	if err = d.Decode(&doc); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to parse experiment YAML")
+		return []SpecProblem{{Message: err.Error()}}
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return []SpecProblem{{Message: "experiment must be an object"}}
+	}
+	problems := []SpecProblem{}
+	for _, k := range sortedKeys(m) {
+		switch k {
+		case "spec":
+			problems = append(problems, checkTasks("spec", m[k])...)
+		case "result":
+			// results are written by Iter8
+		default:
+			problems = append(problems, SpecProblem{Path: k, Message: "unknown field"})
+		}
+	}
+	if _, ok := m["spec"]; !ok {
+		problems = append(problems, SpecProblem{Path: "spec", Message: "experiment has no spec"})
+	}
+	return problems
+}
+
+// checkTasks checks a list of tasks located at the given path
+func checkTasks(path string, v interface{}) []SpecProblem {
+	tasks, ok := v.([]interface{})
+	if !ok {
+		return []SpecProblem{{Path: path, Message: "expected a list of tasks"}}
+	}
+	problems := []SpecProblem{}
+	for i, t := range tasks {
+		tp := fmt.Sprintf("%v[%v]", path, i)
+		m, ok := t.(map[string]interface{})
+		if !ok {
+			problems = append(problems, SpecProblem{Path: tp, Message: "expected a task"})
+			continue
+		}
+		var tt reflect.Type
+		if _, ok := m["run"]; ok {
+			tt = reflect.TypeOf(runTask{})
+		} else if _, ok := m["parallel"]; ok {
+			tt = reflect.TypeOf(parallelTask{})
+		} else if name, ok := m["task"].(string); ok && len(name) > 0 {
+			factory, ok := getTaskFactory(name)
+			if !ok {
+				problems = append(problems, SpecProblem{Path: joinPath(tp, "task"), Message: "unknown task " + name})
+				continue
+			}
+			tt = reflect.TypeOf(factory())
+		} else {
+			problems = append(problems, SpecProblem{Path: tp, Message: "task has no task name, run command, or parallel block"})
+			continue
+		}
+		problems = append(problems, checkValue(tp, t, tt)...)
+	}
+	return problems
+}
+
+// checkValue checks that a decoded JSON value located at the given path can be unmarshaled into type t
+func checkValue(path string, v interface{}, t reflect.Type) []SpecProblem {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// null is acceptable for any type
+	if v == nil {
+		return nil
+	}
+	if t == reflect.TypeOf(ExperimentSpec{}) {
+		return checkTasks(path, v)
+	}
+	// types that unmarshal themselves define their own format
+	if hasCustomUnmarshaler(t) {
+		return nil
+	}
+
+	wrongType := func(expected string) []SpecProblem {
+		return []SpecProblem{{Path: path, Message: "expected " + expected}}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return wrongType("an object")
+		}
+		fields := jsonFields(t)
+		problems := []SpecProblem{}
+		for _, k := range sortedKeys(m) {
+			f, ok := lookupField(fields, k)
+			if !ok {
+				problems = append(problems, SpecProblem{Path: joinPath(path, k), Message: "unknown field"})
+				continue
+			}
+			problems = append(problems, checkValue(joinPath(path, k), m[k], f.Type)...)
+		}
+		return problems
+	case reflect.Map:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return wrongType("an object")
+		}
+		problems := []SpecProblem{}
+		for _, k := range sortedKeys(m) {
+			problems = append(problems, checkValue(joinPath(path, k), m[k], t.Elem())...)
+		}
+		return problems
+	case reflect.Slice, reflect.Array:
+		// byte slices are base64 encoded strings
+		if t.Elem().Kind() == reflect.Uint8 {
+			if _, ok := v.(string); !ok {
+				return wrongType("a string")
+			}
+			return nil
+		}
+		s, ok := v.([]interface{})
+		if !ok {
+			return wrongType("a list")
+		}
+		problems := []SpecProblem{}
+		for i, e := range s {
+			problems = append(problems, checkValue(fmt.Sprintf("%v[%v]", path, i), e, t.Elem())...)
+		}
+		return problems
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			return wrongType("a string")
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			return wrongType("a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := v.(json.Number)
+		if !ok {
+			return wrongType("an integer")
+		}
+		if _, err := n.Int64(); err != nil {
+			return wrongType("an integer")
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := v.(json.Number)
+		if !ok {
+			return wrongType("a non-negative integer")
+		}
+		if i, err := n.Int64(); err != nil || i < 0 {
+			return wrongType("a non-negative integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := v.(json.Number); !ok {
+			return wrongType("a number")
+		}
+	}
+	return nil
+}
+
+// lookupField finds the field for a JSON key
+// like encoding/json, an exact match is preferred, but keys are otherwise matched case-insensitively
+func lookupField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if f, ok := fields[key]; ok {
+		return f, true
+	}
+	for name, f := range fields {
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// sortedStringKeys returns the keys of the map in sorted order
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
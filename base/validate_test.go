@@ -0,0 +1,142 @@
+package base
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+const invalidSpec = `
+spec:
+- task: http
+  with:
+    qsp: 8
+    connections: four
+- task: nosuchtask
+- run: echo hello
+  retries: -1
+- task: ready
+  with:
+    name: httpbin
+- parallel:
+  - task: notify
+`
+
+func TestCheckExperimentYAML(t *testing.T) {
+	problems := CheckExperimentYAML([]byte(invalidSpec))
+	assert.Equal(t, []SpecProblem{
+		{Path: "spec[0].with.connections", Message: "expected an integer"},
+		{Path: "spec[0].with.qsp", Message: "unknown field"},
+		{Path: "spec[1].task", Message: "unknown task nosuchtask"},
+	}, problems)
+
+	// valid structure
+	problems = CheckExperimentYAML([]byte(`
+spec:
+- task: http
+  timeout: 1m
+  with:
+    url: https://httpbin.org/get
+    qps: 8.5
+    errorRanges:
+    - lower: 500
+    endpoints:
+      get:
+        headers:
+          x-user: alice
+- run: echo hello
+result:
+  numLoops: 1
+`))
+	assert.Empty(t, problems)
+
+	// not an experiment
+	problems = CheckExperimentYAML([]byte(`- task: http`))
+	assert.Equal(t, 1, len(problems))
+	problems = CheckExperimentYAML([]byte(`specs: []`))
+	assert.Equal(t, 2, len(problems))
+}
+
+func TestValidateSpec(t *testing.T) {
+	e := &Experiment{}
+	err := yaml.Unmarshal([]byte(`
+spec:
+- task: http
+  backoff: 0s
+  with:
+    qps: 0
+    duration: abc
+- run: echo hello
+  retries: -1
+  if: "Result.NumLoops >"
+- task: ready
+  with:
+    name: httpbin
+    timeout: -1s
+- parallel:
+  - task: notify
+- task: assess
+  with:
+    SLOs:
+      upper:
+      - limit: 10
+`), e)
+	assert.NoError(t, err)
+
+	problems := e.Spec.Validate()
+	paths := []string{}
+	for _, p := range problems {
+		paths = append(paths, p.Path)
+	}
+	assert.Equal(t, []string{
+		"spec[0]",
+		"spec[0].with.duration",
+		"spec[0].with.qps",
+		"spec[0].with.url",
+		"spec[1]",
+		"spec[1].if",
+		"spec[2].with.resource",
+		"spec[2].with.timeout",
+		"spec[3].parallel[0].with.url",
+		"spec[4].with.SLOs.upper[0].metric",
+	}, paths)
+
+	// inputs are valid
+	e = &Experiment{}
+	err = yaml.Unmarshal([]byte(`
+spec:
+- task: http
+  with:
+    url: https://httpbin.org/get
+- run: echo hello
+  if: Result.NumLoops > 1
+- task: custommetrics
+  with:
+    templates: {}
+`), e)
+	assert.NoError(t, err)
+	assert.Empty(t, e.Spec.Validate())
+}
+
+func TestExperimentSchema(t *testing.T) {
+	b, err := ExperimentSchema()
+	assert.NoError(t, err)
+
+	s := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(b, &s))
+	defs := s["definitions"].(map[string]interface{})
+	for _, name := range []string{RunTaskName, ParallelTaskName, CollectHTTPTaskName, CollectGRPCTaskName, AssessTaskName} {
+		assert.Contains(t, defs, "task."+name)
+	}
+
+	http := defs["task."+CollectHTTPTaskName].(map[string]interface{})
+	props := http["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"const": CollectHTTPTaskName}, props["task"])
+	assert.Contains(t, props, "retries")
+	with := props["with"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Contains(t, with, "url")
+	assert.Contains(t, with, "endpoints")
+	assert.Equal(t, false, http["additionalProperties"])
+}
@@ -37,6 +37,14 @@ spec:
                 - ALL
               runAsNonRoot: true
               runAsUser: 1001040000
+            {{- if .Values.volumeMounts }}
+            volumeMounts:
+              {{- toYaml .Values.volumeMounts | nindent 14 }}
+            {{- end }}
+          {{- if .Values.volumes }}
+          volumes:
+            {{- toYaml .Values.volumes | nindent 12 }}
+          {{- end }}
           restartPolicy: Never
       backoffLimit: 0
 {{- end }}
@@ -7,7 +7,7 @@ metadata:
     iter8.tools/group: {{ .Release.Name }}
 rules:
 - apiGroups: [""]
-  resourceNames: [{{ .Release.Name | quote }}]
+  resourceNames: [{{ .Release.Name | quote }}{{ if and .Values.assess .Values.assess.regression }}, {{ printf "%s-history" .Release.Name | quote }}{{ end }}]
   resources: ["secrets"]
   verbs: ["get", "update"]
 {{- if .Values.ready }}
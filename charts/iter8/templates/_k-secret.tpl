@@ -8,4 +8,15 @@ metadata:
 stringData:
   experiment.yaml: |
 {{ include "experiment" . | indent 4 }}
+{{- if and .Values.assess .Values.assess.regression }}
+---
+# history of experiment runs used to detect regressions; kept across revisions
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .Release.Name }}-history
+  annotations:
+    iter8.tools/group: {{ .Release.Name }}
+    helm.sh/resource-policy: keep
+{{- end }}
 {{- end }}
\ No newline at end of file
@@ -36,5 +36,9 @@
 {{- end }}
 {{- end }}
 {{- end }}
+{{- if .regression }}
+    regression:
+{{ toYaml .regression | indent 6 }}
+{{- end }}
 {{- end }}
 {{- end }}
\ No newline at end of file
@@ -3,16 +3,16 @@
 {{- if not . }}
 {{- fail "http values object is nil" }}
 {{- end }}
-{{/* url must be defined or a url must be defined for each endpoint */}}
-{{- if not .url }}
+{{/* url or steps must be defined, or a url or steps must be defined for each endpoint */}}
+{{- if not (or .url .steps) }}
 {{- if .endpoints }}
 {{- range $endpointID, $endpoint := .endpoints }}
-{{- if not $endpoint.url }}
-{{- fail (print "endpoint \"" (print $endpointID "\" does not have a url parameter")) }}
+{{- if not (or $endpoint.url $endpoint.steps) }}
+{{- fail (print "endpoint \"" (print $endpointID "\" does not have a url or steps parameter")) }}
 {{- end }}
 {{- end }}
 {{- else }}
-{{- fail "please set the url parameter or the endpoints parameter" }}
+{{- fail "please set the url parameter, the steps parameter, or the endpoints parameter" }}
 {{- end }}
 {{- end }}
 {{- /**************************/ -}}
@@ -22,6 +22,18 @@ Supported conditions are 'completed', 'nofailure', 'slos', which indicate that t
 	iter8 k assert -c completed -c nofailure -c slos
 	# same as iter8 k assert -c completed,nofailure,slos
 
+Experiments that use sequential testing in the assess task also support the 'decided' condition, which indicates that the outcome is settled and the version can be promoted or rolled back. Use it to stop a looped experiment early:
+
+	iter8 k assert -c decided
+
+Experiments that compute canary scores in the assess task also support the 'score>=N' condition, which indicates that the scores of all candidate versions are at least N:
+
+	iter8 k assert -c completed,nofailure,score>=80
+
+Experiments that check for regressions in the assess task also support the 'noregression' condition, which indicates that no metric of any version is significantly worse than in earlier runs of the experiment. Use it as a performance regression gate in CI:
+
+	iter8 k assert -c completed,nofailure,noregression
+
 You can optionally specify a timeout, which is the maximum amount of time to wait for the conditions to be satisfied:
 
 	iter8 k assert -c completed,nofailure,slos -t 5s
@@ -61,7 +73,7 @@ func newKAssertCmd(kd *driver.KubeDriver) *cobra.Command {
 
 // addConditionFlag adds the condition flag to command
 func addConditionFlag(cmd *cobra.Command, conditionPtr *[]string) {
-	cmd.Flags().StringSliceVarP(conditionPtr, "condition", "c", nil, fmt.Sprintf("%v | %v | %v; can specify multiple or separate conditions with commas;", ia.Completed, ia.NoFailure, ia.SLOs))
+	cmd.Flags().StringSliceVarP(conditionPtr, "condition", "c", nil, fmt.Sprintf("%v | %v | %v | %v | %v | %vN; can specify multiple or separate conditions with commas;", ia.Completed, ia.NoFailure, ia.SLOs, ia.Decided, ia.NoRegression, ia.Score))
 	_ = cmd.MarkFlagRequired("condition")
 }
 
@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"io"
+	"os"
+	"os/signal"
+	"syscall"
 
 	ia "github.com/iter8-tools/iter8/action"
 	"github.com/iter8-tools/iter8/driver"
@@ -15,6 +19,14 @@ Run a Kubernetes experiment. This command reads an experiment specified in a sec
 	$ iter8 k run --namespace {{ .Experiment.Namespace }} --group {{ .Experiment.group }}
 
 This command is intended for use within the Iter8 Docker image that is used to execute Kubernetes experiments.
+
+An experiment that failed or was interrupted can be resumed from its first incomplete or failed task. Tasks that completed earlier are not run again, and the metrics they collected are kept.
+
+	$ iter8 k run --namespace {{ .Experiment.Namespace }} --group {{ .Experiment.group }} --resume
+
+To see what an experiment would do without running it, use plan mode. The inputs of each task are printed after defaults are applied, along with whether its condition is satisfied by the current result. No load is generated, and no metric queries or notifications are sent.
+
+	$ iter8 k run --namespace {{ .Experiment.Namespace }} --group {{ .Experiment.group }} --plan
 `
 
 // newKRunCmd creates the Kubernetes run command
@@ -28,11 +40,19 @@ func newKRunCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 		SilenceUsage: true,
 		Hidden:       true,
 		RunE: func(_ *cobra.Command, _ []string) error {
-			return actor.KubeRun()
+			// interrupt the experiment when the job pod is terminated
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+			defer stop()
+			if actor.Plan {
+				return actor.KubePlan(ctx, out)
+			}
+			return actor.KubeRun(ctx)
 		},
 	}
 	addExperimentGroupFlag(cmd, &actor.Group)
 	addReuseResult(cmd, &actor.ReuseResult)
+	addResume(cmd, &actor.Resume)
+	addPlan(cmd, &actor.Plan)
 	return cmd
 }
 
@@ -41,3 +61,13 @@ func newKRunCmd(kd *driver.KubeDriver, out io.Writer) *cobra.Command {
 func addReuseResult(cmd *cobra.Command, reuseResultPtr *bool) {
 	cmd.Flags().BoolVar(reuseResultPtr, "reuseResult", false, "reuse experiment result; useful for experiments with multiple loops such as Kubernetes experiments with a cronjob runner")
 }
+
+// addResume allows the experiment to be resumed from its first incomplete or failed task
+func addResume(cmd *cobra.Command, resumePtr *bool) {
+	cmd.Flags().BoolVar(resumePtr, "resume", false, "resume experiment from its first incomplete or failed task; insights from completed tasks are kept")
+}
+
+// addPlan allows the experiment to be planned instead of run
+func addPlan(cmd *cobra.Command, planPtr *bool) {
+	cmd.Flags().BoolVar(planPtr, "plan", false, "print what each task would do without running the experiment")
+}
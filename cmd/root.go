@@ -81,6 +81,9 @@ func init() {
 	// add k
 	rootCmd.AddCommand(kcmd)
 
+	// add validate
+	rootCmd.AddCommand(newValidateCmd())
+
 	// add version
 	rootCmd.AddCommand(newVersionCmd())
 
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+
+	ia "github.com/iter8-tools/iter8/action"
+	"github.com/iter8-tools/iter8/base/log"
+	"github.com/spf13/cobra"
+)
+
+// validateDesc is the description of the validate cmd
+const validateDesc = `
+Validate an experiment spec without running it or touching a Kubernetes cluster. All problems found in the spec are reported along with their paths. If there are no problems, the command exits with code 0. Else, the command exits with code 1.
+
+	iter8 validate -f experiment.yaml
+
+Problems are reported as follows:
+
+	spec[0].with.qsp: unknown field
+	spec[1].with.url: url is required
+
+Print the JSON Schema of experiment specs, for use with editors and other tools:
+
+	iter8 validate --schema
+`
+
+// newValidateCmd creates the validate command
+func newValidateCmd() *cobra.Command {
+	actor := ia.NewValidateOpts()
+
+	cmd := &cobra.Command{
+		Use:          "validate",
+		Short:        "Validate an experiment spec",
+		Long:         validateDesc,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			valid, err := actor.Run(outStream)
+			if err != nil {
+				return err
+			}
+			if !valid {
+				e := errors.New("experiment spec is invalid")
+				log.Logger.Error(e)
+				return e
+			}
+			return nil
+		},
+	}
+	addValidateFlags(cmd, actor)
+	return cmd
+}
+
+// addValidateFlags adds flags to the validate command
+func addValidateFlags(cmd *cobra.Command, actor *ia.ValidateOpts) {
+	cmd.Flags().StringVarP(&actor.File, "file", "f", actor.File, "experiment YAML file to validate")
+	cmd.Flags().BoolVar(&actor.Schema, "schema", false, "print the JSON Schema of experiment specs")
+	cmd.Flags().Lookup("schema").NoOptDefVal = "true"
+}
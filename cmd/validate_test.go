@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/iter8-tools/iter8/base"
+)
+
+func TestValidate(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	_ = os.WriteFile("invalid.yaml", []byte(`
+spec:
+- task: http
+  with:
+    qsp: 8
+`), 0600)
+
+	tests := []cmdTestCase{
+		// validate
+		{
+			name: "validate",
+			cmd:  fmt.Sprintf("validate -f %v", base.CompletePath("../testdata", "experiment.yaml")),
+		},
+		// validate invalid spec
+		{
+			name:      "validate invalid spec",
+			cmd:       "validate -f invalid.yaml",
+			wantError: true,
+		},
+		// validate missing file
+		{
+			name:      "validate missing file",
+			cmd:       "validate -f nosuchfile.yaml",
+			wantError: true,
+		},
+		// schema
+		{
+			name: "validate schema",
+			cmd:  "validate --schema",
+		},
+	}
+
+	runTestActionCmd(t, tests)
+}
@@ -9,6 +9,8 @@ import (
 const (
 	// ExperimentPath is the name of the experiment file
 	ExperimentPath = "experiment.yaml"
+	// HistoryPath is the name of the file with the history of experiment runs
+	HistoryPath = "history.yaml"
 	// DefaultExperimentGroup is the name of the default experiment chart
 	DefaultExperimentGroup = "default"
 )
@@ -23,3 +25,14 @@ func ExperimentFromBytes(b []byte) (*base.Experiment, error) {
 	}
 	return &e, err
 }
+
+// historyFromBytes reads the history of experiment runs from bytes
+func historyFromBytes(b []byte) (*base.History, error) {
+	h := base.History{}
+	err := yaml.Unmarshal(b, &h)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to unmarshal history: ", string(b))
+		return nil, err
+	}
+	return &h, err
+}
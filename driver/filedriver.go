@@ -37,6 +37,31 @@ func (f *FileDriver) Write(exp *base.Experiment) error {
 	return nil
 }
 
+// ReadHistory reads the history of experiment runs
+// An empty history is returned if the history file does not exist
+func (f *FileDriver) ReadHistory() (*base.History, error) {
+	b, err := os.ReadFile(path.Join(f.RunDir, HistoryPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return &base.History{}, nil
+	}
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to read history")
+		return nil, errors.New("unable to read history")
+	}
+	return historyFromBytes(b)
+}
+
+// WriteHistory writes the history of experiment runs
+func (f *FileDriver) WriteHistory(h *base.History) error {
+	b, _ := yaml.Marshal(h)
+	err := os.WriteFile(path.Join(f.RunDir, HistoryPath), b, 0600)
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to write history")
+		return errors.New("unable to write history")
+	}
+	return nil
+}
+
 // GetRevision is undefined for file drivers
 func (f *FileDriver) GetRevision() int {
 	return 0
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -25,7 +26,7 @@ func TestLocalRun(t *testing.T) {
 	fd := FileDriver{
 		RunDir: ".",
 	}
-	err := base.RunExperiment(false, &fd)
+	err := base.RunExperiment(context.Background(), false, &fd)
 	assert.NoError(t, err)
 	// sanity check -- handler was called
 	assert.True(t, verifyHandlerCalled)
@@ -45,3 +46,23 @@ func TestFileDriverReadError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, exp)
 }
+
+func TestFileDriverHistory(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	fd := FileDriver{
+		RunDir: ".",
+	}
+	h, err := fd.ReadHistory()
+	assert.NoError(t, err)
+	assert.Empty(t, h.Runs)
+
+	h.Runs = append(h.Runs, base.HistoryRun{
+		Revision: 1,
+		Metrics:  []map[string]float64{{"http/latency-mean": 10}},
+	})
+	assert.NoError(t, fd.WriteHistory(h))
+	h, err = fd.ReadHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(h.Runs))
+	assert.Equal(t, 10.0, h.Runs[0].Metrics[0]["http/latency-mean"])
+}
@@ -258,6 +258,56 @@ func (kd *KubeDriver) Write(e *base.Experiment) error {
 	return nil
 }
 
+// getHistorySecretName yields the name of the secret with the history of experiment runs
+// unlike the experiment secret, its content is kept across revisions of the experiment
+func (kd *KubeDriver) getHistorySecretName() string {
+	return fmt.Sprintf("%v-history", kd.Group)
+}
+
+// ReadHistory reads the history of experiment runs from its secret
+// An empty history is returned if the secret does not exist or has no history
+func (kd *KubeDriver) ReadHistory() (*base.History, error) {
+	secretsClient := kd.Clientset.CoreV1().Secrets(kd.Namespace())
+	s, err := secretsClient.Get(context.Background(), kd.getHistorySecretName(), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return &base.History{}, nil
+	}
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to read history")
+		return nil, errors.New("unable to read history")
+	}
+	b, ok := s.Data[HistoryPath]
+	if !ok {
+		return &base.History{}, nil
+	}
+	return historyFromBytes(b)
+}
+
+// WriteHistory writes the history of experiment runs to its secret
+// The secret is created by the chart along with the experiment; only its data is updated,
+// so that its labels and annotations, which Helm relies on, are kept
+func (kd *KubeDriver) WriteHistory(h *base.History) error {
+	byteArray, err := yaml.Marshal(h)
+	if err != nil {
+		return err
+	}
+	secretsClient := kd.Clientset.CoreV1().Secrets(kd.Namespace())
+	sec, err := secretsClient.Get(context.Background(), kd.getHistorySecretName(), metav1.GetOptions{})
+	if err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to get history secret " + kd.getHistorySecretName())
+		return errors.New("unable to write history")
+	}
+	if sec.Data == nil {
+		sec.Data = map[string][]byte{}
+	}
+	sec.Data[HistoryPath] = byteArray
+	if _, err = secretsClient.Update(context.Background(), sec, metav1.UpdateOptions{}); err != nil {
+		log.Logger.WithStackTrace(err.Error()).Error("unable to write history")
+		return errors.New("unable to write history")
+	}
+	return nil
+}
+
 // GetRevision gets the experiment revision
 func (kd *KubeDriver) GetRevision() int {
 	return kd.revision
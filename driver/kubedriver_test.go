@@ -100,7 +100,7 @@ func TestKubeRun(t *testing.T) {
 		},
 	}, metav1.CreateOptions{})
 
-	err := base.RunExperiment(false, kd)
+	err := base.RunExperiment(context.Background(), false, kd)
 	assert.NoError(t, err)
 	// sanity check -- handler was called
 	assert.True(t, verifyHandlerCalled)
@@ -154,3 +154,48 @@ func TestDryInstall(t *testing.T) {
 	assert.NoError(t, err)
 	assert.FileExists(t, ManifestFile)
 }
+
+func TestKubeDriverHistory(t *testing.T) {
+	_ = os.Chdir(t.TempDir())
+	kd := NewFakeKubeDriver(cli.New())
+	h, err := kd.ReadHistory()
+	assert.NoError(t, err)
+	assert.Empty(t, h.Runs)
+
+	// the secret is created by the chart
+	h.Runs = append(h.Runs, base.HistoryRun{Revision: 1})
+	assert.Error(t, kd.WriteHistory(h))
+	_, err = kd.Clientset.CoreV1().Secrets(kd.Namespace()).Create(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "default-history",
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "Helm"},
+			Annotations: map[string]string{
+				"iter8.tools/group":         "default",
+				"helm.sh/resource-policy":   "keep",
+				"meta.helm.sh/release-name": "default",
+			},
+		},
+	}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// the secret is updated
+	h.Runs = nil
+	for k := 1; k <= 2; k++ {
+		h.Runs = append(h.Runs, base.HistoryRun{
+			Revision: k,
+			Metrics:  []map[string]float64{{"http/latency-mean": float64(10 * k)}},
+		})
+		assert.NoError(t, kd.WriteHistory(h))
+	}
+	sec, err := kd.Clientset.CoreV1().Secrets(kd.Namespace()).Get(context.Background(), "default-history", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(sec.Data[HistoryPath]), "revision: 2")
+	h, err = kd.ReadHistory()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(h.Runs))
+
+	// labels and annotations set by Helm are kept
+	assert.Equal(t, "Helm", sec.Labels["app.kubernetes.io/managed-by"])
+	assert.Equal(t, "keep", sec.Annotations["helm.sh/resource-policy"])
+	assert.Equal(t, "default", sec.Annotations["meta.helm.sh/release-name"])
+}
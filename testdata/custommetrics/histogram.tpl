@@ -0,0 +1,13 @@
+url: http://url/query
+provider: hist-prom
+method: GET
+metrics:
+
+- name: latency
+  type: histogram
+  description: request latency
+  units: sec
+  params:
+  - name: query
+    value: sum by (le) (rate(request_duration_seconds_bucket[5m]))
+  jqExpression: '[.data.result[] | {le: .metric.le, count: .value[1]}]'